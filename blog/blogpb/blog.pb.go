@@ -811,6 +811,9 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type BlogServiceClient interface {
 	// Creates a blog item.
+	// Returns `INVALID_ARGUMENT` if the author ID or title are longer than 1 KiB.
+	// Returns `RESOURCE_EXHAUSTED` if the content or the whole request are over the server's size limits.
+	// Returns `INTERNAL` if DB operation could not be performed.
 	CreateBlog(ctx context.Context, in *CreateBlogRequest, opts ...grpc.CallOption) (*CreateBlogResponse, error)
 	// Retrieves a blog item.
 	// Returns `NOT_FOUND` if the item does not exist.
@@ -819,7 +822,9 @@ type BlogServiceClient interface {
 	ReadBlog(ctx context.Context, in *ReadBlogRequest, opts ...grpc.CallOption) (*ReadBlogResponse, error)
 	// Updates a blog item.
 	// Returns `NOT_FOUND` if the item does not exist.
-	// Returns `INVALID_ARGUMENT` if given blog item ID cannot be converted into ObjectId.
+	// Returns `INVALID_ARGUMENT` if given blog item ID cannot be converted into ObjectId,
+	// or if the author ID or title are longer than 1 KiB.
+	// Returns `RESOURCE_EXHAUSTED` if the content or the whole request are over the server's size limits.
 	// Returns `INTERNAL` if DB operation could not be performed.
 	UpdateBlog(ctx context.Context, in *UpdateBlogRequest, opts ...grpc.CallOption) (*UpdateBlogResponse, error)
 	// Deletes a blog item.
@@ -911,6 +916,9 @@ func (x *blogServiceListBlogClient) Recv() (*ListBlogResponse, error) {
 // BlogServiceServer is the server API for BlogService service.
 type BlogServiceServer interface {
 	// Creates a blog item.
+	// Returns `INVALID_ARGUMENT` if the author ID or title are longer than 1 KiB.
+	// Returns `RESOURCE_EXHAUSTED` if the content or the whole request are over the server's size limits.
+	// Returns `INTERNAL` if DB operation could not be performed.
 	CreateBlog(context.Context, *CreateBlogRequest) (*CreateBlogResponse, error)
 	// Retrieves a blog item.
 	// Returns `NOT_FOUND` if the item does not exist.
@@ -919,7 +927,9 @@ type BlogServiceServer interface {
 	ReadBlog(context.Context, *ReadBlogRequest) (*ReadBlogResponse, error)
 	// Updates a blog item.
 	// Returns `NOT_FOUND` if the item does not exist.
-	// Returns `INVALID_ARGUMENT` if given blog item ID cannot be converted into ObjectId.
+	// Returns `INVALID_ARGUMENT` if given blog item ID cannot be converted into ObjectId,
+	// or if the author ID or title are longer than 1 KiB.
+	// Returns `RESOURCE_EXHAUSTED` if the content or the whole request are over the server's size limits.
 	// Returns `INTERNAL` if DB operation could not be performed.
 	UpdateBlog(context.Context, *UpdateBlogRequest) (*UpdateBlogResponse, error)
 	// Deletes a blog item.
@@ -0,0 +1,204 @@
+// Package blogsdk is a Go SDK for the BlogService.
+package blogsdk
+
+import (
+	"context"
+	"io"
+
+	"github.com/rsorage/grpc-go-course/blog/blogpb"
+	"github.com/rsorage/grpc-go-course/compression"
+	"github.com/rsorage/grpc-go-course/grpcclient"
+	"google.golang.org/grpc"
+)
+
+// Blog is a blog item.
+type Blog struct {
+	ID       string
+	AuthorID string
+	Title    string
+	Content  string
+}
+
+func fromPb(b *blogpb.Blog) *Blog {
+	return &Blog{
+		ID:       b.GetId(),
+		AuthorID: b.GetAuthorId(),
+		Title:    b.GetTitle(),
+		Content:  b.GetContent(),
+	}
+}
+
+func (b Blog) toPb() *blogpb.Blog {
+	return &blogpb.Blog{
+		Id:       b.ID,
+		AuthorId: b.AuthorID,
+		Title:    b.Title,
+		Content:  b.Content,
+	}
+}
+
+// Client calls the BlogService.
+type Client struct {
+	cc  *grpc.ClientConn
+	rpc blogpb.BlogServiceClient
+	cfg config
+}
+
+// New connects to the BlogService at target.
+func New(target string, opts ...Option) (*Client, error) {
+	cfg := config{compressor: compression.Gzip}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cc, err := grpcclient.Dial(target, cfg.dialOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		cc:  cc,
+		rpc: blogpb.NewBlogServiceClient(cc),
+		cfg: cfg,
+	}, nil
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.cc.Close()
+}
+
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.cfg.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.cfg.timeout)
+}
+
+// Create creates a blog item and returns it with its ID set.
+func (c *Client) Create(ctx context.Context, blog Blog) (*Blog, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.rpc.CreateBlog(ctx, &blogpb.CreateBlogRequest{Blog: blog.toPb()})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return fromPb(res.GetBlog()), nil
+}
+
+// Get retrieves the blog item with the given ID.
+func (c *Client) Get(ctx context.Context, id string) (*Blog, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.rpc.ReadBlog(ctx, &blogpb.ReadBlogRequest{Id: id})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return fromPb(res.GetBlog()), nil
+}
+
+// Update replaces the blog item identified by blog.ID.
+func (c *Client) Update(ctx context.Context, blog Blog) (*Blog, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	res, err := c.rpc.UpdateBlog(ctx, &blogpb.UpdateBlogRequest{Blog: blog.toPb()})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return fromPb(res.GetBlog()), nil
+}
+
+// Delete deletes the blog item with the given ID.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	_, err := c.rpc.DeleteBlog(ctx, &blogpb.DeleteBlogRequest{Id: id})
+	return mapError(err)
+}
+
+// List returns an iterator over all blog items, fetched pageSize items at a
+// time. A pageSize of zero fetches everything at once.
+func (c *Client) List(ctx context.Context, pageSize uint32) *Iterator {
+	return &Iterator{ctx: ctx, client: c, size: pageSize}
+}
+
+// Iterator walks through the blog items returned by List, transparently
+// fetching the next page when the current one is exhausted.
+//
+//	it := client.List(ctx, 20)
+//	for it.Next() {
+//		fmt.Println(it.Blog().Title)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	ctx    context.Context
+	client *Client
+	size   uint32
+
+	page uint32
+	buf  []*Blog
+	cur  *Blog
+	done bool
+	err  error
+}
+
+// Next advances to the next blog item. It returns false when there are no
+// more items or an error occurred.
+func (it *Iterator) Next() bool {
+	for len(it.buf) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+
+	it.cur, it.buf = it.buf[0], it.buf[1:]
+	return true
+}
+
+// Blog returns the current blog item.
+func (it *Iterator) Blog() *Blog {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+func (it *Iterator) fetch() {
+	ctx, cancel := it.client.withTimeout(it.ctx)
+	defer cancel()
+
+	stream, err := it.client.rpc.ListBlog(ctx, &blogpb.Pageable{Page: it.page, Size: it.size})
+	if err != nil {
+		it.err = mapError(err)
+		return
+	}
+
+	var n uint32
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			it.err = mapError(err)
+			return
+		}
+		it.buf = append(it.buf, fromPb(res.GetBlog()))
+		n++
+	}
+
+	// a short page is the last one
+	it.page++
+	if it.size == 0 || n < it.size {
+		it.done = true
+	}
+}
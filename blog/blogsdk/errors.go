@@ -0,0 +1,103 @@
+package blogsdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrNotFound is returned when the requested blog item does not exist.
+	ErrNotFound = errors.New("blog not found")
+
+	// ErrInvalidID is returned when a blog item ID is not a valid ObjectId.
+	ErrInvalidID = errors.New("invalid blog id")
+
+	// ErrInvalidBlog is returned when fields of a blog item are invalid,
+	// such as a title that is too long.
+	ErrInvalidBlog = errors.New("invalid blog")
+
+	// ErrTooLarge is returned when the content of a blog item, or a whole
+	// request or response, is over the size limits of the server or of
+	// the client. The message describes the limit.
+	ErrTooLarge = errors.New("blog too large")
+
+	// ErrDeadlineTooShort is returned when the server refuses a call
+	// because it has less time left than the server requires. The message
+	// describes the minimum.
+	ErrDeadlineTooShort = errors.New("deadline too short")
+)
+
+// Reasons of the google.rpc.ErrorInfo details of the server errors.
+const (
+	reasonContentTooLarge  = "CONTENT_TOO_LARGE"
+	reasonMessageTooLarge  = "MESSAGE_TOO_LARGE"
+	reasonDeadlineTooShort = "DEADLINE_TOO_SHORT"
+)
+
+// mapError translates gRPC statuses into the SDK errors. The server's
+// message is kept, so errors.Is must be used to compare them.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	switch st.Code() {
+	case codes.NotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, st.Message())
+	case codes.InvalidArgument:
+		if hasDetail(st, func(d interface{}) bool {
+			_, ok := d.(*errdetails.BadRequest)
+			return ok
+		}) {
+			return fmt.Errorf("%w: %s", ErrInvalidBlog, st.Message())
+		}
+		return fmt.Errorf("%w: %s", ErrInvalidID, st.Message())
+	case codes.ResourceExhausted:
+		if tooLarge(st) {
+			return fmt.Errorf("%w: %s", ErrTooLarge, st.Message())
+		}
+	case codes.FailedPrecondition:
+		if hasReason(st, reasonDeadlineTooShort) {
+			return fmt.Errorf("%w: %s", ErrDeadlineTooShort, st.Message())
+		}
+	}
+	return err
+}
+
+// tooLarge reports whether st is a size limit error, as opposed to a rate
+// limit one. The errors of gRPC itself carry no details and are recognized
+// by their message.
+func tooLarge(st *status.Status) bool {
+	if strings.Contains(st.Message(), "larger than max") {
+		return true
+	}
+	return hasReason(st, reasonContentTooLarge) || hasReason(st, reasonMessageTooLarge)
+}
+
+// hasReason reports whether st has a google.rpc.ErrorInfo detail with the
+// given reason.
+func hasReason(st *status.Status, reason string) bool {
+	return hasDetail(st, func(d interface{}) bool {
+		info, ok := d.(*errdetails.ErrorInfo)
+		return ok && info.GetReason() == reason
+	})
+}
+
+func hasDetail(st *status.Status, match func(interface{}) bool) bool {
+	for _, d := range st.Details() {
+		if match(d) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package blogsdk
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMapError(t *testing.T) {
+	withReason := func(c codes.Code, reason string) error {
+		st, err := status.New(c, "msg").WithDetails(&errdetails.ErrorInfo{Reason: reason})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Err()
+	}
+	badRequest, err := status.New(codes.InvalidArgument, "msg").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "blog.title"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error // nil when the error must be returned as is
+	}{
+		{"not found", status.Error(codes.NotFound, "msg"), ErrNotFound},
+		{"invalid id", status.Error(codes.InvalidArgument, "msg"), ErrInvalidID},
+		{"invalid blog", badRequest.Err(), ErrInvalidBlog},
+		{"content too large", withReason(codes.ResourceExhausted, "CONTENT_TOO_LARGE"), ErrTooLarge},
+		{"message too large", withReason(codes.ResourceExhausted, "MESSAGE_TOO_LARGE"), ErrTooLarge},
+		{"grpc message too large", status.Error(codes.ResourceExhausted, "grpc: received message larger than max (5 vs. 4)"), ErrTooLarge},
+		{"rate limited", status.Error(codes.ResourceExhausted, "Rate limit exceeded"), nil},
+		{"deadline too short", withReason(codes.FailedPrecondition, "DEADLINE_TOO_SHORT"), ErrDeadlineTooShort},
+		{"other precondition", status.Error(codes.FailedPrecondition, "msg"), nil},
+		{"unavailable", status.Error(codes.Unavailable, "msg"), nil},
+	}
+	sentinels := []error{ErrNotFound, ErrInvalidID, ErrInvalidBlog, ErrTooLarge, ErrDeadlineTooShort}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapError(tt.err)
+			if tt.want == nil {
+				if got != tt.err {
+					t.Errorf("mapError() = %v, want %v unchanged", got, tt.err)
+				}
+				return
+			}
+			for _, s := range sentinels {
+				if errors.Is(got, s) != (s == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", got, s, !(s == tt.want))
+				}
+			}
+		})
+	}
+
+	if mapError(nil) != nil {
+		t.Error("mapError(nil) != nil")
+	}
+}
@@ -0,0 +1,102 @@
+package blogsdk
+
+import (
+	"context"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/compression"
+	"github.com/rsorage/grpc-go-course/grpcclient"
+	"google.golang.org/grpc"
+)
+
+type config struct {
+	token         string
+	tls           bool
+	caFile        string
+	timeout       time.Duration
+	compressor    string
+	clientOptions []grpcclient.Option
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+// WithToken authenticates every call with the given bearer token.
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// WithTLS enables transport security, trusting the CA certificate in
+// caFile. An empty caFile uses the system roots.
+func WithTLS(caFile string) Option {
+	return func(c *config) {
+		c.tls = true
+		c.caFile = caFile
+	}
+}
+
+// WithTimeout bounds every call, and every page of a listing, to d. It only
+// shortens deadlines already set on the context.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// WithCompression compresses the blog items sent and received by Create,
+// Get, Update and List with the named compressor, see package compression.
+// Those calls use gzip by default; compression.Identity disables it.
+func WithCompression(name string) Option {
+	return func(c *config) {
+		c.compressor = name
+	}
+}
+
+// contentMethods carry blog contents, which are worth compressing.
+var contentMethods = []string{
+	"/blog.BlogService/CreateBlog",
+	"/blog.BlogService/ReadBlog",
+	"/blog.BlogService/UpdateBlog",
+	"/blog.BlogService/ListBlog",
+}
+
+// WithClientOptions passes extra options to grpcclient.Dial.
+func WithClientOptions(opts ...grpcclient.Option) Option {
+	return func(c *config) {
+		c.clientOptions = append(c.clientOptions, opts...)
+	}
+}
+
+func (c *config) dialOptions() []grpcclient.Option {
+	var opts []grpcclient.Option
+	if c.tls {
+		opts = append(opts, grpcclient.WithTLS(c.caFile))
+	}
+	if c.token != "" {
+		opts = append(opts, grpcclient.WithDialOptions(
+			grpc.WithPerRPCCredentials(tokenAuth{token: c.token, secure: c.tls}),
+		))
+	}
+	if c.compressor != compression.Identity {
+		for _, method := range contentMethods {
+			opts = append(opts, grpcclient.WithMethodCompression(method, c.compressor))
+		}
+	}
+	return append(opts, c.clientOptions...)
+}
+
+// tokenAuth sends a bearer token in the authorization metadata.
+type tokenAuth struct {
+	token  string
+	secure bool
+}
+
+func (t tokenAuth) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenAuth) RequireTransportSecurity() bool {
+	return t.secure
+}
@@ -0,0 +1,54 @@
+package blogserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultOperationTimeout bounds MongoDB operations of calls without
+	// a deadline.
+	DefaultOperationTimeout = 10 * time.Second
+
+	// responseReserve is the part of a call's remaining time kept for
+	// sending the response after the MongoDB operation, so that a slow
+	// operation fails with a meaningful error before the client gives up.
+	responseReserve = 50 * time.Millisecond
+)
+
+// dbContext returns the context of a MongoDB operation done on behalf of
+// the call of ctx. The operation gets the call's remaining time, minus
+// responseReserve, and at most the operation timeout. It fails with
+// DEADLINE_EXCEEDED if there is no time left for the operation.
+func (s *Server) dbContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	budget := s.opTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline) - responseReserve; left < budget {
+			budget = left
+		}
+	}
+	if budget <= 0 {
+		return nil, nil, status.Error(codes.DeadlineExceeded, "Not enough time left for the database operation")
+	}
+
+	dbCtx, cancel := context.WithTimeout(ctx, budget)
+	return dbCtx, cancel, nil
+}
+
+// dbError converts the error of a MongoDB operation to a status. Timeouts
+// and cancellations keep their meaning; anything else is an internal error
+// described by msg.
+func dbError(ctx context.Context, err error, msg string) error {
+	switch {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, context.DeadlineExceeded) || mongo.IsTimeout(err):
+		return status.Errorf(codes.DeadlineExceeded, "%s: database operation timed out", msg)
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
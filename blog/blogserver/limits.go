@@ -0,0 +1,82 @@
+package blogserver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/blog/blogpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultMaxContentSize is the largest blog content accepted by
+	// default, in bytes.
+	DefaultMaxContentSize = 1 << 20
+
+	// MaxContentSize bounds WithMaxContentSize, leaving room in MongoDB's
+	// 16 MiB documents for the other fields.
+	MaxContentSize = 15 << 20
+
+	// maxFieldSize is the largest author ID and title, in bytes.
+	maxFieldSize = 1 << 10
+)
+
+// ContentTooLarge is the reason of the google.rpc.ErrorInfo detail of the
+// errors returned for blog contents over the limit.
+const ContentTooLarge = "CONTENT_TOO_LARGE"
+
+// WithMaxContentSize limits the content of the blog items created and
+// updated to n bytes. New fails unless n is between 1 and MaxContentSize.
+func WithMaxContentSize(n int) Option {
+	return func(s *Server) {
+		s.maxContent = n
+	}
+}
+
+// checkBlog returns INVALID_ARGUMENT if the author ID or title of blog are
+// too long, and RESOURCE_EXHAUSTED if its content is over the limit. Both
+// describe the limit, in a google.rpc.BadRequest and a google.rpc.ErrorInfo
+// detail respectively.
+func (s *Server) checkBlog(blog *blogpb.Blog) error {
+	var fields []string
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, f := range []struct{ name, value string }{
+		{"author_id", blog.GetAuthorId()},
+		{"title", blog.GetTitle()},
+	} {
+		if len(f.value) > maxFieldSize {
+			fields = append(fields, f.name)
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{
+				Field:       "blog." + f.name,
+				Description: fmt.Sprintf("Must be at most %d bytes, got %d", maxFieldSize, len(f.value)),
+			})
+		}
+	}
+	if len(violations) > 0 {
+		st := status.Newf(codes.InvalidArgument, "Blog %s must be at most %d bytes", strings.Join(fields, " and "), maxFieldSize)
+		if detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	if size := len(blog.GetContent()); size > s.maxContent {
+		st := status.Newf(codes.ResourceExhausted, "Blog content is %d bytes, larger than the limit of %d bytes", size, s.maxContent)
+		detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+			Reason: ContentTooLarge,
+			Domain: "grpc-go-course",
+			Metadata: map[string]string{
+				"size":  strconv.Itoa(size),
+				"limit": strconv.Itoa(s.maxContent),
+			},
+		})
+		if err == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+// Package blogserver implements the BlogService on top of MongoDB.
+package blogserver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/blog/blogpb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Server implements blogpb.BlogServiceServer.
+type Server struct {
+	collection *mongo.Collection
+	opTimeout  time.Duration
+	maxContent int
+}
+
+// Option configures New.
+type Option func(*Server)
+
+// WithOperationTimeout bounds MongoDB operations of calls without a
+// deadline, or with a later one, to d.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.opTimeout = d
+	}
+}
+
+// New creates a BlogService implementation storing blog items in the given
+// collection. MongoDB operations are bounded by the deadline of the call and
+// by DefaultOperationTimeout, and blog contents by DefaultMaxContentSize.
+// It fails if the options are out of range.
+func New(collection *mongo.Collection, opts ...Option) (*Server, error) {
+	s := &Server{
+		collection: collection,
+		opTimeout:  DefaultOperationTimeout,
+		maxContent: DefaultMaxContentSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.maxContent <= 0 || s.maxContent > MaxContentSize {
+		return nil, fmt.Errorf("max content size %d must be between 1 and %d bytes", s.maxContent, MaxContentSize)
+	}
+	return s, nil
+}
+
+type blogItem struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	AuthorID string             `bson:"author_id,omitempty"`
+	Content  string             `bson:"content,omitempty"`
+	Title    string             `bson:"title,omitempty"`
+}
+
+type Pageable struct {
+	Page int64
+	Size int64
+}
+
+func (p Pageable) GetPage() *int64 {
+	return &p.Size
+}
+
+func (p Pageable) CalcOffset() *int64 {
+	o := p.Page * p.Size
+	return &o
+}
+
+func fromPbPageable(pbp *blogpb.Pageable) Pageable {
+	return Pageable{
+		Page: int64(pbp.Page),
+		Size: int64(pbp.Size),
+	}
+
+	// if pbp.Page != nil {
+	// 	p.Page = pbp.Page
+	// }
+
+	// if pbp.Size != nil {
+	// 	p.Size = pbp.Size
+	// }
+
+}
+
+func (blog blogItem) toBlogPb() *blogpb.Blog {
+	return &blogpb.Blog{
+		Id:       blog.ID.Hex(),
+		AuthorId: blog.AuthorID,
+		Title:    blog.Title,
+		Content:  blog.Content,
+	}
+}
+
+func (s *Server) CreateBlog(ctx context.Context, req *blogpb.CreateBlogRequest) (*blogpb.CreateBlogResponse, error) {
+	log.Printf("Create blog request: %v\n", req)
+	blog := req.GetBlog()
+	if err := s.checkBlog(blog); err != nil {
+		return nil, err
+	}
+
+	data := blogItem{
+		AuthorID: blog.GetAuthorId(),
+		Content:  blog.GetContent(),
+		Title:    blog.GetTitle(),
+	}
+
+	dbCtx, cancel, err := s.dbContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	res, err := s.collection.InsertOne(dbCtx, data)
+	if err != nil {
+		return nil, dbError(ctx, err, "Error inserting document")
+	}
+
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Cannot convert to OID: %v", err))
+	}
+
+	return &blogpb.CreateBlogResponse{
+		Blog: &blogpb.Blog{
+			Id:       oid.Hex(),
+			AuthorId: blog.GetAuthorId(),
+			Content:  blog.GetContent(),
+			Title:    blog.Title,
+		},
+	}, nil
+}
+
+func (s *Server) ReadBlog(ctx context.Context, req *blogpb.ReadBlogRequest) (*blogpb.ReadBlogResponse, error) {
+	log.Printf("Read blog request: %v\n", req)
+
+	oid, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		log.Printf("Impossible to convert to ObjectID: %s\n", req.GetId())
+		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("Impossible to convert to ObjectID: %s\n", req.GetId()))
+	}
+
+	var blog blogItem
+	filter := bson.M{"_id": oid}
+
+	dbCtx, cancel, err := s.dbContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	log.Println("Searching the DB...")
+	err = s.collection.FindOne(dbCtx, filter).Decode(&blog)
+	if err != nil && err != mongo.ErrNoDocuments {
+		log.Printf("id='%s' Error reading blog item: %v\n", oid.Hex(), err)
+		return nil, dbError(ctx, err, "Error reading document")
+	}
+	if err != nil {
+		log.Printf("id='%s' No blog item found!\n", oid.Hex())
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("id='%s' No blog item found!", oid.Hex()))
+	}
+
+	log.Println("BlogItem retrieved from DB!")
+	return &blogpb.ReadBlogResponse{Blog: blog.toBlogPb()}, nil
+}
+
+func (s *Server) UpdateBlog(ctx context.Context, req *blogpb.UpdateBlogRequest) (*blogpb.UpdateBlogResponse, error) {
+	blog := req.GetBlog()
+
+	log.Printf("Updating blog item: %v\n", blog)
+
+	oid, err := primitive.ObjectIDFromHex(blog.GetId())
+	if err != nil {
+		log.Printf("Impossible to convert to ObjectId: %s", blog.GetId())
+		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("id='%s' Cannot parse to ObjectId.", blog.GetId()))
+	}
+	if err := s.checkBlog(blog); err != nil {
+		return nil, err
+	}
+
+	data := blogItem{
+		AuthorID: blog.GetAuthorId(),
+		Content:  blog.GetContent(),
+		Title:    blog.GetTitle(),
+	}
+	filter := bson.M{"_id": oid}
+
+	dbCtx, cancel, err := s.dbContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	res, err := s.collection.ReplaceOne(dbCtx, filter, data)
+	if err != nil {
+		log.Printf("id='%s'\tImpossible to update blog item: %v", oid.Hex(), err)
+		return nil, dbError(ctx, err, "Error updating document")
+	}
+	if res.MatchedCount == 0 {
+		log.Printf("id='%s' No blog item found!", oid.Hex())
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("id='%s' Impossible to find blog item!", oid.Hex()))
+	}
+
+	return &blogpb.UpdateBlogResponse{
+		Blog: &blogpb.Blog{
+			Id:       oid.Hex(),
+			AuthorId: blog.GetAuthorId(),
+			Title:    blog.GetTitle(),
+			Content:  blog.GetContent(),
+		},
+	}, nil
+}
+
+func (s *Server) DeleteBlog(ctx context.Context, req *blogpb.DeleteBlogRequest) (*emptypb.Empty, error) {
+	id := req.GetId()
+
+	log.Printf("id='%s' Deleting blog item...", id)
+
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		log.Printf("id='%s' Cannot convert into ObjectId!\n", id)
+		return nil, status.Errorf(codes.InvalidArgument, fmt.Sprintf("Impossible to convert '%s' into ObjectId", id))
+	}
+
+	filter := bson.M{"_id": oid}
+
+	dbCtx, cancel, err := s.dbContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	res, err := s.collection.DeleteOne(dbCtx, filter)
+	if err != nil {
+		log.Printf("MongoDB error: %v", err)
+		return nil, dbError(ctx, err, "Error deleting document")
+	}
+	if res.DeletedCount == 0 {
+		log.Printf("id='%s' No blog item found!", id)
+		return nil, status.Errorf(codes.NotFound, fmt.Sprintf("No blog item found with id: %v", id))
+	}
+
+	log.Printf("id='%s' Blog item deleted!", id)
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ListBlog(pbp *blogpb.Pageable, stream blogpb.BlogService_ListBlogServer) error {
+	p := fromPbPageable(pbp)
+
+	log.Printf("page=%v size=%v Listing blog items...", p.Page, p.Size)
+
+	filter := bson.M{}
+	opts := options.FindOptions{
+		Limit: p.GetPage(),
+		Skip:  p.CalcOffset(),
+		Sort:  bson.M{"_id": 1},
+	}
+
+	ctx := stream.Context()
+	dbCtx, cancel, err := s.dbContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	cur, err := s.collection.Find(dbCtx, filter, &opts)
+	if err != nil {
+		log.Printf("Internal MongoDB error: %v", err)
+		return dbError(ctx, err, "Error finding blog items")
+	}
+
+	var bis []blogItem
+	if err = cur.All(dbCtx, &bis); err != nil {
+		log.Printf("Internal MongoDB error: %v", err)
+		return dbError(ctx, err, "Error getting blog items")
+	}
+
+	for _, bi := range bis {
+		err := stream.Send(&blogpb.ListBlogResponse{
+			Blog: bi.toBlogPb(),
+		})
+		if err != nil {
+			log.Printf("Error sending blog item: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ConnectMongo connects to the MongoDB server at the given URI.
+func ConnectMongo(uri string) *mongo.Client {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log.Println("Connecting to MongoDB server...")
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		log.Fatalf("Erro connecting to MongoDB server: %v", err)
+	}
+
+	log.Println("Connected successfully to MongoDB server!")
+	return client
+}
@@ -0,0 +1,30 @@
+package blogserver
+
+import "testing"
+
+func TestNewMaxContentSize(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		want int
+		ok   bool
+	}{
+		{nil, DefaultMaxContentSize, true},
+		{[]Option{WithMaxContentSize(1)}, 1, true},
+		{[]Option{WithMaxContentSize(MaxContentSize)}, MaxContentSize, true},
+		{[]Option{WithMaxContentSize(MaxContentSize + 1)}, 0, false},
+		{[]Option{WithMaxContentSize(0)}, 0, false},
+		{[]Option{WithMaxContentSize(-1)}, 0, false},
+	}
+	for _, tt := range tests {
+		s, err := New(nil, tt.opts...)
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("New() with max content %d succeeded", s.maxContent)
+			}
+			continue
+		}
+		if err != nil || s.maxContent != tt.want {
+			t.Errorf("New() = %v, want max content %d", err, tt.want)
+		}
+	}
+}
@@ -2,118 +2,286 @@ package main
 
 import (
 	"context"
-	"io"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"time"
 
-	"github.com/rsorage/grpc-go-course/blog/blogpb"
-	"google.golang.org/grpc"
+	"github.com/rsorage/grpc-go-course/blog/blogsdk"
+	"github.com/rsorage/grpc-go-course/compression"
 )
 
+const usage = `Usage: blog [flags] <command> [command flags]
+
+Commands:
+  create   create a blog item from flags or a JSON/Markdown file
+  get      print a blog item
+  update   update a blog item from flags or a JSON/Markdown file
+  delete   delete a blog item
+  list     list all blog items
+  watch    poll the blog items and print the changes
+
+Flags:
+`
+
+type cli struct {
+	client *blogsdk.Client
+	out    *printer
+}
+
 func main() {
-	opts := grpc.WithInsecure()
+	log.SetFlags(0)
+
+	addr := flag.String("addr", "localhost:50051", "BlogService address")
+	tls := flag.Bool("tls", false, "enable TLS")
+	caFile := flag.String("ca", "ssl/ca.crt", "CA trust certificate used with -tls")
+	token := flag.String("token", "", "bearer token sent with every call")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each call")
+	output := flag.String("o", "table", "output format: table, json or yaml")
+	compressor := flag.String("compression", compression.Gzip, "compressor of the blog items: identity, gzip, zstd or snappy")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	out, err := newPrinter(os.Stdout, *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	opts := []blogsdk.Option{
+		blogsdk.WithTimeout(*timeout),
+		blogsdk.WithCompression(*compressor),
+	}
+	if *tls {
+		opts = append(opts, blogsdk.WithTLS(*caFile))
+	}
+	if *token != "" {
+		opts = append(opts, blogsdk.WithToken(*token))
+	}
 
-	cc, err := grpc.Dial("localhost:50051", opts)
+	client, err := blogsdk.New(*addr, opts...)
 	if err != nil {
 		log.Fatalf("Could not connect: %v", err)
 	}
-	defer cc.Close()
+	defer client.Close()
 
-	c := blogpb.NewBlogServiceClient(cc)
+	c := &cli{client: client, out: out}
 
-	createBlog(c)
-	// readBlog(c, "6137cbfe24772434d19bc92")
-	// updateBlog(c)
-	// deleteBlog(c)
-	listBlogs(c)
-}
+	commands := map[string]func(context.Context, []string) error{
+		"create": c.create,
+		"get":    c.get,
+		"update": c.update,
+		"delete": c.delete,
+		"list":   c.list,
+		"watch":  c.watch,
+	}
 
-func createBlog(c blogpb.BlogServiceClient) {
-	log.Println("Creating the blog...")
-	blog := blogpb.Blog{
-		AuthorId: "rsorage",
-		Title:    "My first blog",
-		Content:  "Content of first blog",
+	name, args := flag.Arg(0), flag.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		log.Printf("Unknown command %q\n\n", name)
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	res, err := c.CreateBlog(context.Background(), &blogpb.CreateBlogRequest{Blog: &blog})
-	if err != nil {
-		log.Fatalf("Unexpected error: %v\n", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, args); err != nil {
+		log.Fatalf("%s: %v", name, err)
 	}
-	log.Printf("Blog has been created: %v\n", res)
 }
 
-func readBlog(c blogpb.BlogServiceClient, id string) {
-	log.Printf("id='%s' Reading blog...\n", id)
+// blogFlags registers the flags used to fill in a blog item.
+func blogFlags(fs *flag.FlagSet) (author, title, content, file *string) {
+	author = fs.String("author", "", "author ID")
+	title = fs.String("title", "", "title")
+	content = fs.String("content", "", "content")
+	file = fs.String("f", "", "read the blog item from a JSON or Markdown file (- for stdin)")
+	return
+}
+
+// readBlog builds a blog item from the file, if any, with the flags that
+// were explicitly set taking precedence.
+func readBlog(fs *flag.FlagSet, base blogsdk.Blog, author, title, content, file string) (blogsdk.Blog, error) {
+	blog := base
+	if file != "" {
+		var err error
+		if blog, err = readBlogFile(file, blog); err != nil {
+			return blog, err
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "author":
+			blog.AuthorID = author
+		case "title":
+			blog.Title = title
+		case "content":
+			blog.Content = content
+		}
+	})
+
+	return blog, nil
+}
 
-	req := &blogpb.ReadBlogRequest{Id: id}
+func (c *cli) create(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	author, title, content, file := blogFlags(fs)
+	fs.Parse(args)
 
-	res, err := c.ReadBlog(context.Background(), req)
+	blog, err := readBlog(fs, blogsdk.Blog{}, *author, *title, *content, *file)
 	if err != nil {
-		log.Printf("Error retrieving blog item: %v\n", err)
-		return
+		return err
 	}
 
-	log.Printf("Blog item retrieved: %v\n", res)
+	created, err := c.client.Create(ctx, blog)
+	if err != nil {
+		return err
+	}
+	return c.out.blog(created)
 }
 
-func updateBlog(c blogpb.BlogServiceClient) {
-	id := "6137d413ca5e9c29f1c44df5"
+func (c *cli) get(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: get <id>")
+	}
+
+	blog, err := c.client.Get(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return c.out.blog(blog)
+}
 
-	log.Printf("id='%s' Reading blog...\n", id)
+func (c *cli) update(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	author, title, content, file := blogFlags(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: update [flags] <id>")
+	}
 
-	data := &blogpb.UpdateBlogRequest{
-		Blog: &blogpb.Blog{
-			Id:       id,
-			AuthorId: "rsorage",
-			Title:    "My updated blog title",
-			Content:  "Updated content...",
-		},
+	// fields that are not given keep their current value
+	current, err := c.client.Get(ctx, fs.Arg(0))
+	if err != nil {
+		return err
 	}
 
-	req, err := c.UpdateBlog(context.Background(), data)
+	blog, err := readBlog(fs, *current, *author, *title, *content, *file)
 	if err != nil {
-		log.Printf("Error updating blog item: %v\n", err)
-		return
+		return err
 	}
+	blog.ID = current.ID
 
-	log.Printf("id='%s' Blog item updated: %v", id, req)
+	updated, err := c.client.Update(ctx, blog)
+	if err != nil {
+		return err
+	}
+	return c.out.blog(updated)
 }
 
-func deleteBlog(c blogpb.BlogServiceClient) {
-	id := "6137cbfe24772434d19bc92b"
+func (c *cli) delete(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: delete <id>...")
+	}
+
+	for _, id := range fs.Args() {
+		if err := c.client.Delete(ctx, id); err != nil {
+			return err
+		}
+		log.Printf("Blog item %s deleted!", id)
+	}
+	return nil
+}
 
-	log.Printf("id='%s' Deleting blog item...\n", id)
+func (c *cli) list(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	pageSize := fs.Uint("page-size", 50, "number of blog items fetched per call")
+	fs.Parse(args)
 
-	_, err := c.DeleteBlog(context.Background(), &blogpb.DeleteBlogRequest{Id: id})
+	blogs, err := c.fetchAll(ctx, uint32(*pageSize))
 	if err != nil {
-		log.Fatalf("Error deleting blog item: %v", err)
-		return
+		return err
 	}
-
-	log.Println("Blog item deleted!")
+	return c.out.blogs(blogs)
 }
 
-func listBlogs(c blogpb.BlogServiceClient) {
-	log.Println("Listing blog items...")
+func (c *cli) fetchAll(ctx context.Context, pageSize uint32) ([]*blogsdk.Blog, error) {
+	var blogs []*blogsdk.Blog
 
-	stream, err := c.ListBlog(context.Background(), &blogpb.Pageable{})
-	if err != nil {
-		log.Fatalf("Error opening stream: %v", err)
-		return
+	it := c.client.List(ctx, pageSize)
+	for it.Next() {
+		blogs = append(blogs, it.Blog())
 	}
+	return blogs, it.Err()
+}
+
+// watch polls the blog items and prints every item that was created,
+// updated or deleted since the previous poll.
+func (c *cli) watch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", 5*time.Second, "polling interval")
+	pageSize := fs.Uint("page-size", 50, "number of blog items fetched per call")
+	fs.Parse(args)
+
+	var known map[string]blogsdk.Blog
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
 
 	for {
-		res, err := stream.Recv()
-		if err == io.EOF {
-			log.Printf("Server closed stream!")
-			break
+		blogs, err := c.fetchAll(ctx, uint32(*pageSize))
+		if ctx.Err() != nil {
+			return nil
 		}
 		if err != nil {
-			log.Fatalf("Error receiving blog items: %v", err)
-			break
+			return err
 		}
 
-		log.Printf("Receiving blog item: %v\n", res.Blog)
-	}
+		current := make(map[string]blogsdk.Blog, len(blogs))
+		var events []event
+		for _, b := range blogs {
+			current[b.ID] = *b
+			old, ok := known[b.ID]
+			switch {
+			case known == nil:
+				events = append(events, event{Type: "EXISTING", Blog: b})
+			case !ok:
+				events = append(events, event{Type: "CREATED", Blog: b})
+			case old != *b:
+				events = append(events, event{Type: "UPDATED", Blog: b})
+			}
+		}
+		for id, b := range known {
+			if _, ok := current[id]; !ok {
+				b := b
+				events = append(events, event{Type: "DELETED", Blog: &b})
+			}
+		}
+		known = current
 
+		if err := c.out.events(events); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/blog/blogsdk"
+	"gopkg.in/yaml.v3"
+)
+
+// blogDoc is the JSON and YAML representation of a blog item.
+type blogDoc struct {
+	ID       string `json:"id,omitempty" yaml:"id,omitempty"`
+	AuthorID string `json:"author_id,omitempty" yaml:"author_id,omitempty"`
+	Title    string `json:"title,omitempty" yaml:"title,omitempty"`
+	Content  string `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+func toDoc(b *blogsdk.Blog) blogDoc {
+	return blogDoc{ID: b.ID, AuthorID: b.AuthorID, Title: b.Title, Content: b.Content}
+}
+
+// merge overwrites the fields of b that are set in the document.
+func (d blogDoc) merge(b blogsdk.Blog) blogsdk.Blog {
+	if d.AuthorID != "" {
+		b.AuthorID = d.AuthorID
+	}
+	if d.Title != "" {
+		b.Title = d.Title
+	}
+	if d.Content != "" {
+		b.Content = d.Content
+	}
+	return b
+}
+
+// readBlogFile reads a blog item from a JSON or Markdown file, or from
+// stdin when path is "-". Fields missing from the file keep the value they
+// have in base.
+func readBlogFile(path string, base blogsdk.Blog) (blogsdk.Blog, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return base, err
+	}
+
+	var doc blogDoc
+	if isJSON(path, data) {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return base, fmt.Errorf("parsing %s: %v", path, err)
+		}
+	} else if doc, err = parseMarkdown(data); err != nil {
+		return base, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	return doc.merge(base), nil
+}
+
+func isJSON(path string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return true
+	case ".md", ".markdown":
+		return false
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
+
+// parseMarkdown reads a Markdown blog post. The author and title may be
+// given in a YAML front matter block:
+//
+//	---
+//	author_id: rsorage
+//	title: My first blog
+//	---
+//	Content of first blog
+//
+// Without front matter, a leading "# Heading" line is used as the title.
+// The rest of the document is the content.
+func parseMarkdown(data []byte) (blogDoc, error) {
+	var doc blogDoc
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	if strings.HasPrefix(text, "---\n") {
+		end := strings.Index(text[4:], "\n---")
+		if end < 0 {
+			return doc, fmt.Errorf("unterminated front matter")
+		}
+		if err := yaml.Unmarshal([]byte(text[4:4+end]), &doc); err != nil {
+			return doc, fmt.Errorf("front matter: %v", err)
+		}
+		text = text[4+end+len("\n---"):]
+		text = strings.TrimPrefix(text, "\n")
+	} else if strings.HasPrefix(text, "# ") {
+		line := text
+		if i := strings.Index(text, "\n"); i >= 0 {
+			line, text = text[:i], text[i+1:]
+		} else {
+			text = ""
+		}
+		doc.Title = strings.TrimSpace(strings.TrimPrefix(line, "# "))
+	}
+
+	if content := strings.TrimSpace(text); content != "" {
+		doc.Content = content
+	}
+	return doc, nil
+}
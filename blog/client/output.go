@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rsorage/grpc-go-course/blog/blogsdk"
+	"gopkg.in/yaml.v3"
+)
+
+// printer writes blog items as a table, JSON or YAML.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "table", "json", "yaml":
+		return &printer{w: w, format: format}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+type event struct {
+	Type string
+	Blog *blogsdk.Blog
+}
+
+func (p *printer) blog(b *blogsdk.Blog) error {
+	switch p.format {
+	case "json":
+		return p.json(toDoc(b))
+	case "yaml":
+		return p.yaml(toDoc(b))
+	}
+	return p.table([]*blogsdk.Blog{b}, nil)
+}
+
+func (p *printer) blogs(bs []*blogsdk.Blog) error {
+	docs := make([]blogDoc, len(bs))
+	for i, b := range bs {
+		docs[i] = toDoc(b)
+	}
+
+	switch p.format {
+	case "json":
+		return p.json(docs)
+	case "yaml":
+		return p.yaml(docs)
+	}
+	return p.table(bs, nil)
+}
+
+// events prints watch events. JSON events are written one per line so the
+// output can be consumed while watching.
+func (p *printer) events(events []event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	switch p.format {
+	case "json", "yaml":
+		for _, e := range events {
+			doc := struct {
+				Type string  `json:"type" yaml:"type"`
+				Blog blogDoc `json:"blog" yaml:"blog"`
+			}{e.Type, toDoc(e.Blog)}
+
+			var err error
+			if p.format == "json" {
+				err = json.NewEncoder(p.w).Encode(doc)
+			} else {
+				fmt.Fprintln(p.w, "---")
+				err = p.yaml(doc)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	bs := make([]*blogsdk.Blog, len(events))
+	types := make([]string, len(events))
+	for i, e := range events {
+		bs[i], types[i] = e.Blog, e.Type
+	}
+	return p.table(bs, types)
+}
+
+func (p *printer) json(v interface{}) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func (p *printer) yaml(v interface{}) error {
+	enc := yaml.NewEncoder(p.w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// table prints one blog item per row, with the content shortened to its
+// first line. events, when given, adds a leading EVENT column.
+func (p *printer) table(bs []*blogsdk.Blog, events []string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+
+	if events != nil {
+		fmt.Fprint(tw, "EVENT\t")
+	}
+	fmt.Fprintln(tw, "ID\tAUTHOR\tTITLE\tCONTENT")
+
+	for i, b := range bs {
+		if events != nil {
+			fmt.Fprintf(tw, "%s\t", events[i])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", b.ID, b.AuthorID, b.Title, summary(b.Content, 40))
+	}
+
+	return tw.Flush()
+}
+
+func summary(s string, max int) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i] + "..."
+	}
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max-3]) + "..."
+	}
+	return s
+}
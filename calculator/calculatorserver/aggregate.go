@@ -0,0 +1,101 @@
+package calculatorserver
+
+import (
+	"io"
+	"math"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/stats"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bounds of the windows. maxWindowCount also bounds the values a sliding
+// time-based window may hold.
+const (
+	maxWindowCount    = 1000000
+	maxWindowDuration = time.Hour
+)
+
+var aggregates = map[calculatorpb.RunningAggregateOptions_Aggregate]stats.Aggregate{
+	calculatorpb.RunningAggregateOptions_MIN:  stats.Min,
+	calculatorpb.RunningAggregateOptions_MAX:  stats.Max,
+	calculatorpb.RunningAggregateOptions_SUM:  stats.Sum,
+	calculatorpb.RunningAggregateOptions_MEAN: stats.Mean,
+	calculatorpb.RunningAggregateOptions_EWMA: stats.EWMA,
+}
+
+// windowOptions validates the options of a RunningAggregate call.
+func windowOptions(opts *calculatorpb.RunningAggregateOptions) (stats.WindowOptions, error) {
+	agg, ok := aggregates[opts.GetAggregate()]
+	if !ok {
+		return stats.WindowOptions{}, status.Errorf(codes.InvalidArgument, "Unknown aggregate %v", opts.GetAggregate())
+	}
+	if a := opts.GetAlpha(); !(a >= 0 && a <= 1) {
+		return stats.WindowOptions{}, status.Errorf(codes.InvalidArgument, "Alpha must be between 0 and 1, got %v", a)
+	}
+
+	count, millis := opts.GetWindowCount(), opts.GetWindowMillis()
+	switch {
+	case count != 0 && millis != 0:
+		return stats.WindowOptions{}, status.Error(codes.InvalidArgument, "Only one of window_count and window_millis may be set")
+	case count < 0 || count > maxWindowCount:
+		return stats.WindowOptions{}, status.Errorf(codes.InvalidArgument, "window_count must be between 0 and %d, got %d", maxWindowCount, count)
+	case millis < 0 || time.Duration(millis) > maxWindowDuration/time.Millisecond:
+		return stats.WindowOptions{}, status.Errorf(codes.InvalidArgument, "window_millis must be between 0 and %d, got %d", maxWindowDuration.Milliseconds(), millis)
+	}
+
+	return stats.WindowOptions{
+		Aggregate: agg,
+		Alpha:     opts.GetAlpha(),
+		Size:      int(count),
+		Duration:  time.Duration(millis) * time.Millisecond,
+		Tumbling:  opts.GetWindow() == calculatorpb.RunningAggregateOptions_TUMBLING,
+	}, nil
+}
+
+func (*Server) RunningAggregate(stream calculatorpb.CalculatorService_RunningAggregateServer) error {
+	var window *stats.Window
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if opts := req.GetOptions(); opts != nil {
+			if window != nil {
+				return status.Error(codes.InvalidArgument, "Options are only allowed in the first message")
+			}
+			wopts, err := windowOptions(opts)
+			if err != nil {
+				return err
+			}
+			window = stats.NewWindow(wopts)
+			continue
+		}
+		if window == nil {
+			return status.Error(codes.InvalidArgument, "The first message must hold the options")
+		}
+
+		n := req.GetNumber()
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return status.Errorf(codes.InvalidArgument, "Number must be finite, got %v", n)
+		}
+
+		value, count := window.Add(n, time.Now())
+		if count > maxWindowCount {
+			return status.Errorf(codes.ResourceExhausted, "Window holds more than %d numbers, use a shorter window_millis", maxWindowCount)
+		}
+		if err := stream.Send(&calculatorpb.RunningAggregateResponse{
+			Value: value,
+			Count: int64(count),
+		}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package calculatorserver
+
+import (
+	"context"
+	"io"
+	"log"
+	"math/big"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBigDigits bounds the size of the big integers accepted and returned.
+const maxBigDigits = 4096
+
+// averagePrecision is the number of fractional digits of AverageBig.
+const averagePrecision = 32
+
+// parseBig parses a decimal integer.
+func parseBig(field, s string) (*big.Int, error) {
+	s = strings.TrimSpace(s)
+	if len(strings.TrimLeft(s, "+-")) > maxBigDigits {
+		return nil, status.Errorf(codes.OutOfRange, "%s has more than %d digits", field, maxBigDigits)
+	}
+
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a decimal integer: %q", field, s)
+	}
+	return n, nil
+}
+
+// checkBig returns OUT_OF_RANGE if n has too many digits to be returned.
+func checkBig(n *big.Int) error {
+	if len(new(big.Int).Abs(n).String()) > maxBigDigits {
+		return status.Errorf(codes.OutOfRange, "Result has more than %d digits", maxBigDigits)
+	}
+	return nil
+}
+
+func (*Server) Sum64(ctx context.Context, req *calculatorpb.Sum64Request) (*calculatorpb.Sum64Response, error) {
+	a, b := req.GetA(), req.GetB()
+	result := a + b
+
+	// overflow happened if both operands have the same sign and the result
+	// has the opposite one
+	if (a >= 0) == (b >= 0) && (result >= 0) != (a >= 0) {
+		return nil, status.Errorf(codes.OutOfRange, "%d + %d overflows int64", a, b)
+	}
+
+	return &calculatorpb.Sum64Response{Result: result}, nil
+}
+
+func (*Server) SumBig(ctx context.Context, req *calculatorpb.SumBigRequest) (*calculatorpb.SumBigResponse, error) {
+	a, err := parseBig("a", req.GetA())
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseBig("b", req.GetB())
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Int).Add(a, b)
+	if err := checkBig(result); err != nil {
+		return nil, err
+	}
+
+	return &calculatorpb.SumBigResponse{Result: result.String()}, nil
+}
+
+func (*Server) Average64(stream calculatorpb.CalculatorService_Average64Server) error {
+	sum := new(big.Int)
+	count := 0
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Error receiving stream: %v", err)
+			return err
+		}
+
+		sum.Add(sum, big.NewInt(req.GetNumber()))
+		count++
+	}
+
+	if count == 0 {
+		return status.Error(codes.InvalidArgument, "Cannot average an empty stream")
+	}
+
+	avg, _ := new(big.Rat).SetFrac(sum, big.NewInt(int64(count))).Float64()
+	return stream.SendAndClose(&calculatorpb.Average64Response{Result: avg})
+}
+
+func (*Server) AverageBig(stream calculatorpb.CalculatorService_AverageBigServer) error {
+	sum := new(big.Int)
+	count := int64(0)
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Error receiving stream: %v", err)
+			return err
+		}
+
+		n, err := parseBig("number", req.GetNumber())
+		if err != nil {
+			return err
+		}
+		sum.Add(sum, n)
+		count++
+	}
+
+	if count == 0 {
+		return status.Error(codes.InvalidArgument, "Cannot average an empty stream")
+	}
+
+	avg := new(big.Rat).SetFrac(sum, big.NewInt(count))
+	return stream.SendAndClose(&calculatorpb.AverageBigResponse{
+		Result: trimZeros(avg.FloatString(averagePrecision)),
+	})
+}
+
+// trimZeros removes the trailing zeros of a decimal's fractional part.
+func trimZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func (*Server) FindMaximum64(stream calculatorpb.CalculatorService_FindMaximum64Server) error {
+	var max int64
+	first := true
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if n := req.GetNumber(); first || n > max {
+			max, first = n, false
+			if err := stream.Send(&calculatorpb.FindMaximum64Response{Max: max}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (*Server) FindMaximumBig(stream calculatorpb.CalculatorService_FindMaximumBigServer) error {
+	var max *big.Int
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		n, err := parseBig("number", req.GetNumber())
+		if err != nil {
+			return err
+		}
+
+		if max == nil || n.Cmp(max) > 0 {
+			max = n
+			if err := stream.Send(&calculatorpb.FindMaximumBigResponse{Max: max.String()}); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package calculatorserver
+
+import (
+	"context"
+	"math"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/units"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (*Server) Convert(ctx context.Context, req *calculatorpb.ConvertRequest) (*calculatorpb.ConvertResponse, error) {
+	result, dim, err := units.Builtin.Convert(req.GetValue(), req.GetFrom(), req.GetTo())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if math.IsInf(result, 0) && !math.IsInf(req.GetValue(), 0) {
+		return nil, status.Errorf(codes.OutOfRange, "%v %s is out of range in %s", req.GetValue(), req.GetFrom(), req.GetTo())
+	}
+
+	return &calculatorpb.ConvertResponse{
+		Result:    result,
+		Dimension: string(dim),
+	}, nil
+}
@@ -0,0 +1,110 @@
+package calculatorserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/expr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// evalFuncs are the functions available to Evaluate. sqrt performs the
+// same domain check as the SquareRoot RPC.
+var evalFuncs = func() map[string]expr.Func {
+	funcs := expr.Builtins()
+	funcs["sqrt"] = expr.UnaryFunc(squareRoot)
+	return funcs
+}()
+
+func (s *Server) Evaluate(ctx context.Context, req *calculatorpb.EvaluateRequest) (*calculatorpb.EvaluateResponse, error) {
+	log.Printf("Receiving call to Evaluate with: %v\n", req)
+
+	tree, err := expr.Parse(req.GetExpression())
+	if err != nil {
+		return nil, expressionError(err)
+	}
+
+	// variables of the request shadow those of the session
+	vars, err := s.sessionVars(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if vars == nil {
+		vars = req.GetVariables()
+	} else {
+		for name, v := range req.GetVariables() {
+			vars[name] = v
+		}
+	}
+
+	result, err := expr.Eval(tree, expr.Env{Vars: vars, Funcs: evalFuncs})
+	if err != nil {
+		return nil, expressionError(err)
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return nil, status.Errorf(codes.OutOfRange, "Result is not a finite number: %v", result)
+	}
+
+	if err := s.record(ctx, "Evaluate", req.GetExpression(), result); err != nil {
+		return nil, err
+	}
+
+	response := &calculatorpb.EvaluateResponse{Result: result}
+	if req.GetIncludeTree() {
+		response.Tree = toPbNode(tree)
+	}
+	return response, nil
+}
+
+// expressionError converts an *expr.Error into INVALID_ARGUMENT with an
+// ExpressionError detail carrying its position.
+func expressionError(err error) error {
+	var exprErr *expr.Error
+	if !errors.As(err, &exprErr) {
+		return status.Errorf(codes.Internal, "Evaluating expression: %v", err)
+	}
+
+	st := status.New(codes.InvalidArgument, exprErr.Error())
+	detailed, detailErr := st.WithDetails(&calculatorpb.ExpressionError{
+		Position: int32(exprErr.Pos),
+		Message:  exprErr.Msg,
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func toPbNode(n expr.Node) *calculatorpb.ExpressionNode {
+	pb := &calculatorpb.ExpressionNode{Position: int32(n.Pos())}
+
+	switch n := n.(type) {
+	case *expr.Number:
+		pb.Node = &calculatorpb.ExpressionNode_Number{Number: n.Value}
+	case *expr.Var:
+		pb.Node = &calculatorpb.ExpressionNode_Variable{Variable: n.Name}
+	case *expr.Unary:
+		pb.Node = &calculatorpb.ExpressionNode_Unary{Unary: &calculatorpb.UnaryOperation{
+			Operator: n.Op,
+			Operand:  toPbNode(n.Operand),
+		}}
+	case *expr.Binary:
+		pb.Node = &calculatorpb.ExpressionNode_Binary{Binary: &calculatorpb.BinaryOperation{
+			Operator: n.Op,
+			Left:     toPbNode(n.Left),
+			Right:    toPbNode(n.Right),
+		}}
+	case *expr.Call:
+		call := &calculatorpb.FunctionCall{Name: n.Name}
+		for _, a := range n.Args {
+			call.Arguments = append(call.Arguments, toPbNode(a))
+		}
+		pb.Node = &calculatorpb.ExpressionNode_Call{Call: call}
+	}
+
+	return pb
+}
@@ -0,0 +1,93 @@
+package calculatorserver
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/prime"
+	"google.golang.org/grpc/status"
+)
+
+// factorize64 calls send with each prime factor of n, once with its
+// exponent when group is set and once per occurrence otherwise.
+func factorize64(ctx context.Context, n uint64, group bool, send func(p uint64, exponent int) error) error {
+	err := prime.Factorize64(ctx, n, func(f prime.Factor64) error {
+		if group {
+			return send(f.Prime, f.Exponent)
+		}
+		for i := 0; i < f.Exponent; i++ {
+			if err := send(f.Prime, 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return contextError(ctx, err)
+}
+
+// factorizeBig is factorize64 for big integers.
+func factorizeBig(ctx context.Context, n *big.Int, group bool, send func(p *big.Int, exponent int) error) error {
+	err := prime.FactorizeBig(ctx, n, func(f prime.FactorBig) error {
+		if group {
+			return send(f.Prime, f.Exponent)
+		}
+		for i := 0; i < f.Exponent; i++ {
+			if err := send(f.Prime, 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return contextError(ctx, err)
+}
+
+// contextError converts err to a status if it is the error of ctx.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && err == ctx.Err() {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+func (*Server) DecomposePrimeNumber(req *calculatorpb.DecomposePrimeNumberRequest, stream calculatorpb.CalculatorService_DecomposePrimeNumberServer) error {
+	number := req.GetNumber()
+	if number < 2 {
+		return nil
+	}
+
+	return factorize64(stream.Context(), uint64(number), req.GetGroupExponents(), func(p uint64, exponent int) error {
+		return stream.Send(&calculatorpb.DecomposePrimeNumberResponse{
+			Result:   int32(p),
+			Exponent: int32(exponent),
+		})
+	})
+}
+
+func (*Server) DecomposePrimeNumber64(req *calculatorpb.DecomposePrimeNumber64Request, stream calculatorpb.CalculatorService_DecomposePrimeNumber64Server) error {
+	number := req.GetNumber()
+	if number < 2 {
+		return nil
+	}
+
+	return factorize64(stream.Context(), uint64(number), req.GetGroupExponents(), func(p uint64, exponent int) error {
+		return stream.Send(&calculatorpb.DecomposePrimeNumber64Response{
+			Result:   int64(p),
+			Exponent: int32(exponent),
+		})
+	})
+}
+
+func (*Server) DecomposePrimeNumberBig(req *calculatorpb.DecomposePrimeNumberBigRequest, stream calculatorpb.CalculatorService_DecomposePrimeNumberBigServer) error {
+	number, err := parseBig("number", req.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	return factorizeBig(stream.Context(), number, req.GetGroupExponents(), func(p *big.Int, exponent int) error {
+		return stream.Send(&calculatorpb.DecomposePrimeNumberBigResponse{
+			Result:   p.String(),
+			Exponent: int32(exponent),
+		})
+	})
+}
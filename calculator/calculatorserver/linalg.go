@@ -0,0 +1,168 @@
+package calculatorserver
+
+import (
+	"context"
+	"io"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/linalg"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMatrixDim bounds the number of rows and columns of the matrices,
+// which the cubic operations would otherwise make arbitrarily slow. The
+// left-hand matrix of MatrixMultiplyStream may have any number of rows.
+const maxMatrixDim = 1000
+
+// toMatrix converts and validates a matrix message.
+func toMatrix(field string, pm *calculatorpb.Matrix) (*linalg.Matrix, error) {
+	rows := pm.GetRows()
+	if len(rows) > maxMatrixDim || len(rows) > 0 && len(rows[0].GetValues()) > maxMatrixDim {
+		return nil, status.Errorf(codes.OutOfRange, "%s has more than %d rows or columns", field, maxMatrixDim)
+	}
+
+	values := make([][]float64, len(rows))
+	for i, row := range rows {
+		values[i] = row.GetValues()
+	}
+
+	m, err := linalg.FromRows(values)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s: %v", field, err)
+	}
+	return m, nil
+}
+
+func toPbMatrix(m *linalg.Matrix) *calculatorpb.Matrix {
+	pm := &calculatorpb.Matrix{}
+	for _, row := range m.Rows() {
+		pm.Rows = append(pm.Rows, &calculatorpb.Vector{Values: row})
+	}
+	return pm
+}
+
+// linalgError converts the errors of the linalg package, which are all
+// caused by the operands.
+func linalgError(err error) error {
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func (*Server) DotProduct(ctx context.Context, req *calculatorpb.DotProductRequest) (*calculatorpb.DotProductResponse, error) {
+	result, err := linalg.Dot(req.GetA().GetValues(), req.GetB().GetValues())
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.DotProductResponse{Result: result}, nil
+}
+
+func (*Server) CrossProduct(ctx context.Context, req *calculatorpb.CrossProductRequest) (*calculatorpb.CrossProductResponse, error) {
+	result, err := linalg.Cross(req.GetA().GetValues(), req.GetB().GetValues())
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.CrossProductResponse{Result: &calculatorpb.Vector{Values: result}}, nil
+}
+
+func (*Server) MatrixMultiply(ctx context.Context, req *calculatorpb.MatrixMultiplyRequest) (*calculatorpb.MatrixMultiplyResponse, error) {
+	a, err := toMatrix("a", req.GetA())
+	if err != nil {
+		return nil, err
+	}
+	b, err := toMatrix("b", req.GetB())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := linalg.Mul(a, b)
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.MatrixMultiplyResponse{Result: toPbMatrix(result)}, nil
+}
+
+func (*Server) Transpose(ctx context.Context, req *calculatorpb.TransposeRequest) (*calculatorpb.TransposeResponse, error) {
+	m, err := toMatrix("matrix", req.GetMatrix())
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorpb.TransposeResponse{Result: toPbMatrix(linalg.Transpose(m))}, nil
+}
+
+func (*Server) Determinant(ctx context.Context, req *calculatorpb.DeterminantRequest) (*calculatorpb.DeterminantResponse, error) {
+	m, err := toMatrix("matrix", req.GetMatrix())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := linalg.Det(m)
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.DeterminantResponse{Result: result}, nil
+}
+
+func (*Server) Inverse(ctx context.Context, req *calculatorpb.InverseRequest) (*calculatorpb.InverseResponse, error) {
+	m, err := toMatrix("matrix", req.GetMatrix())
+	if err != nil {
+		return nil, err
+	}
+
+	result, cond, err := linalg.Inverse(m)
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.InverseResponse{Result: toPbMatrix(result), Condition: cond}, nil
+}
+
+func (*Server) SolveLinearSystem(ctx context.Context, req *calculatorpb.SolveLinearSystemRequest) (*calculatorpb.SolveLinearSystemResponse, error) {
+	a, err := toMatrix("a", req.GetA())
+	if err != nil {
+		return nil, err
+	}
+
+	x, cond, err := linalg.Solve(a, req.GetB().GetValues())
+	if err != nil {
+		return nil, linalgError(err)
+	}
+	return &calculatorpb.SolveLinearSystemResponse{X: &calculatorpb.Vector{Values: x}, Condition: cond}, nil
+}
+
+func (*Server) MatrixMultiplyStream(stream calculatorpb.CalculatorService_MatrixMultiplyStreamServer) error {
+	var b *linalg.Matrix
+
+	for index := int64(0); ; {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if pb := req.GetB(); pb != nil {
+			if b != nil {
+				return status.Error(codes.InvalidArgument, "The right-hand matrix may only be sent once")
+			}
+			if b, err = toMatrix("b", pb); err != nil {
+				return err
+			}
+			continue
+		}
+		if b == nil {
+			return status.Error(codes.InvalidArgument, "The first message must hold the right-hand matrix")
+		}
+
+		row, err := linalg.MulRow(req.GetRow().GetValues(), b)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "row %d: %v", index, err)
+		}
+		if err := stream.Send(&calculatorpb.MatrixMultiplyStreamResponse{
+			Index: index,
+			Row:   &calculatorpb.Vector{Values: row},
+		}); err != nil {
+			return err
+		}
+		index++
+	}
+}
@@ -0,0 +1,66 @@
+package calculatorserver
+
+import (
+	"context"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/prime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxNthPrime bounds the index accepted by NthPrime, whose cost grows with
+// the size of the prime.
+const maxNthPrime = 50000000
+
+func (*Server) IsPrime(ctx context.Context, req *calculatorpb.IsPrimeRequest) (*calculatorpb.IsPrimeResponse, error) {
+	n, err := parseBig("number", req.GetNumber())
+	if err != nil {
+		return nil, err
+	}
+
+	return &calculatorpb.IsPrimeResponse{
+		IsPrime:  prime.IsPrimeBig(n),
+		Probable: !n.IsUint64() && n.Sign() > 0,
+	}, nil
+}
+
+func (*Server) NthPrime(ctx context.Context, req *calculatorpb.NthPrimeRequest) (*calculatorpb.NthPrimeResponse, error) {
+	n := req.GetN()
+	if n < 1 {
+		return nil, status.Errorf(codes.InvalidArgument, "n must be positive, got %d", n)
+	}
+	if n > maxNthPrime {
+		return nil, status.Errorf(codes.OutOfRange, "n must be at most %d, got %d", maxNthPrime, n)
+	}
+
+	p, err := prime.NthPrime(ctx, uint64(n))
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	return &calculatorpb.NthPrimeResponse{Prime: int64(p)}, nil
+}
+
+func (*Server) PrimesInRange(req *calculatorpb.PrimesInRangeRequest, stream calculatorpb.CalculatorService_PrimesInRangeServer) error {
+	from, to := req.GetFrom(), req.GetTo()
+	if from > to {
+		return status.Errorf(codes.InvalidArgument, "from (%d) is greater than to (%d)", from, to)
+	}
+	if to < 2 {
+		return nil
+	}
+	if from < 0 {
+		from = 0
+	}
+
+	ctx := stream.Context()
+	err := prime.Sieve(ctx, uint64(from), uint64(to), func(primes []uint64) error {
+		res := &calculatorpb.PrimesInRangeResponse{Primes: make([]int64, len(primes))}
+		for i, p := range primes {
+			res.Primes[i] = int64(p)
+		}
+		return stream.Send(res)
+	})
+	return contextError(ctx, err)
+}
@@ -0,0 +1,126 @@
+package calculatorserver
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxDecimalDigits bounds the fractional digits of RationalToDecimal.
+const maxDecimalDigits = 1000
+
+// parseRational parses a fraction message.
+func parseRational(field string, r *calculatorpb.Rational) (*big.Rat, error) {
+	num, err := parseBig(field+".numerator", r.GetNumerator())
+	if err != nil {
+		return nil, err
+	}
+
+	den := big.NewInt(1)
+	if strings.TrimSpace(r.GetDenominator()) != "" {
+		if den, err = parseBig(field+".denominator", r.GetDenominator()); err != nil {
+			return nil, err
+		}
+	}
+	if den.Sign() == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "%s.denominator is zero", field)
+	}
+
+	return new(big.Rat).SetFrac(num, den), nil
+}
+
+func toPbRational(x *big.Rat) (*calculatorpb.Rational, error) {
+	if err := checkBig(x.Num()); err != nil {
+		return nil, err
+	}
+	if err := checkBig(x.Denom()); err != nil {
+		return nil, err
+	}
+	return &calculatorpb.Rational{
+		Numerator:   x.Num().String(),
+		Denominator: x.Denom().String(),
+	}, nil
+}
+
+func (*Server) RationalArithmetic(ctx context.Context, req *calculatorpb.RationalArithmeticRequest) (*calculatorpb.RationalArithmeticResponse, error) {
+	a, err := parseRational("a", req.GetA())
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseRational("b", req.GetB())
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(big.Rat)
+	switch op := req.GetOperator(); op {
+	case calculatorpb.RationalArithmeticRequest_ADD:
+		result.Add(a, b)
+	case calculatorpb.RationalArithmeticRequest_SUBTRACT:
+		result.Sub(a, b)
+	case calculatorpb.RationalArithmeticRequest_MULTIPLY:
+		result.Mul(a, b)
+	case calculatorpb.RationalArithmeticRequest_DIVIDE:
+		if b.Sign() == 0 {
+			return nil, status.Error(codes.InvalidArgument, "Division by zero")
+		}
+		result.Quo(a, b)
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown operator %v", op)
+	}
+
+	pr, err := toPbRational(result)
+	if err != nil {
+		return nil, err
+	}
+	return &calculatorpb.RationalArithmeticResponse{Result: pr}, nil
+}
+
+func (*Server) RationalToDecimal(ctx context.Context, req *calculatorpb.RationalToDecimalRequest) (*calculatorpb.RationalToDecimalResponse, error) {
+	x, err := parseRational("value", req.GetValue())
+	if err != nil {
+		return nil, err
+	}
+
+	digits := req.GetDigits()
+	if digits < 0 || digits > maxDecimalDigits {
+		return nil, status.Errorf(codes.InvalidArgument, "digits must be between 0 and %d, got %d", maxDecimalDigits, digits)
+	}
+
+	return &calculatorpb.RationalToDecimalResponse{
+		Result: roundHalfEven(x, int(digits)),
+	}, nil
+}
+
+// roundHalfEven formats x as a decimal with the given number of fractional
+// digits, rounding ties to the even digit as is customary for money.
+func roundHalfEven(x *big.Rat, digits int) string {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	scaled := new(big.Rat).Mul(x, new(big.Rat).SetInt(scale))
+
+	q, r := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	twiceRem := new(big.Int).Lsh(new(big.Int).Abs(r), 1)
+	if c := twiceRem.Cmp(scaled.Denom()); c > 0 || c == 0 && q.Bit(0) == 1 {
+		if scaled.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+
+	s := new(big.Int).Abs(q).String()
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if q.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
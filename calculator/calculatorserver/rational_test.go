@@ -0,0 +1,79 @@
+package calculatorserver
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoundHalfEven(t *testing.T) {
+	tests := []struct {
+		x      string
+		digits int
+		want   string
+	}{
+		{"5/2", 0, "2"},
+		{"7/2", 0, "4"},
+		{"-5/2", 0, "-2"},
+		{"-7/2", 0, "-4"},
+		{"1/8", 2, "0.12"},
+		{"3/8", 2, "0.38"},
+		{"-1/8", 2, "-0.12"},
+		{"1251/1000", 2, "1.25"},
+		{"12501/10000", 2, "1.25"},
+		{"1/200", 2, "0.00"},
+		{"3/200", 2, "0.02"},
+		{"-1/200", 2, "0.00"},
+		{"-3/1000", 2, "0.00"},
+		{"-6/1000", 2, "-0.01"},
+		{"1/3", 5, "0.33333"},
+		{"2/3", 5, "0.66667"},
+		{"1/7", 20, "0.14285714285714285714"},
+		{"123", 3, "123.000"},
+		{"1/1000000", 3, "0.000"},
+		{"999/1000", 2, "1.00"},
+		{"-999/1000", 2, "-1.00"},
+	}
+	for _, tt := range tests {
+		x, _ := new(big.Rat).SetString(tt.x)
+		if got := roundHalfEven(x, tt.digits); got != tt.want {
+			t.Errorf("roundHalfEven(%s, %d) = %s, want %s", tt.x, tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestRationalArithmeticIsExact(t *testing.T) {
+	s := New()
+	// 1/10 + 2/10 is exactly 3/10, unlike in floating point
+	res, err := s.RationalArithmetic(context.Background(), &calculatorpb.RationalArithmeticRequest{
+		A:        &calculatorpb.Rational{Numerator: "1", Denominator: "10"},
+		B:        &calculatorpb.Rational{Numerator: "2", Denominator: "10"},
+		Operator: calculatorpb.RationalArithmeticRequest_ADD,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.GetResult(); got.GetNumerator() != "3" || got.GetDenominator() != "10" {
+		t.Errorf("1/10 + 2/10 = %s/%s, want 3/10", got.GetNumerator(), got.GetDenominator())
+	}
+
+	_, err = s.RationalArithmetic(context.Background(), &calculatorpb.RationalArithmeticRequest{
+		A:        &calculatorpb.Rational{Numerator: "1"},
+		B:        &calculatorpb.Rational{Numerator: "0", Denominator: "5"},
+		Operator: calculatorpb.RationalArithmeticRequest_DIVIDE,
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("division by zero = %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestConvertOutOfRange(t *testing.T) {
+	_, err := New().Convert(context.Background(), &calculatorpb.ConvertRequest{Value: 1e308, From: "km", To: "nm"})
+	if status.Code(err) != codes.OutOfRange {
+		t.Errorf("Convert(1e308 km to nm) = %v, want %v", err, codes.OutOfRange)
+	}
+}
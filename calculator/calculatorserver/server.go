@@ -0,0 +1,170 @@
+// Package calculatorserver implements the CalculatorService.
+package calculatorserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/session"
+	"github.com/rsorage/grpc-go-course/heartbeat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements calculatorpb.CalculatorServiceServer.
+type Server struct {
+	sessions session.Store
+}
+
+// Option configures New.
+type Option func(*Server)
+
+// WithSessionStore keeps the sessions in store.
+func WithSessionStore(store session.Store) Option {
+	return func(s *Server) {
+		s.sessions = store
+	}
+}
+
+// New creates a CalculatorService implementation. Unless configured
+// otherwise, sessions are kept in memory for session.DefaultTTL.
+func New(opts ...Option) *Server {
+	s := &Server{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.sessions == nil {
+		s.sessions = session.NewMemoryStore(session.DefaultTTL, session.DefaultMaxSessions)
+	}
+	return s
+}
+
+func (s *Server) Sum(ctx context.Context, req *calculatorpb.SumRequest) (*calculatorpb.SumResponse, error) {
+	result := int64(req.A) + int64(req.B)
+
+	input := fmt.Sprintf("%d + %d", req.A, req.B)
+	if err := s.record(ctx, "Sum", input, float64(result)); err != nil {
+		return nil, err
+	}
+
+	response := &calculatorpb.SumResponse{
+		Result: result,
+	}
+
+	return response, nil
+}
+
+func (*Server) Average(stream calculatorpb.CalculatorService_AverageServer) error {
+	var sum, count int64
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Error receiving stream: %v", err)
+			return err
+		}
+		log.Printf("Request received: %v", req)
+		sum += int64(req.GetNumber())
+		count++
+	}
+
+	if count == 0 {
+		return status.Error(codes.InvalidArgument, "Cannot average an empty stream")
+	}
+
+	log.Printf("Calculating average of %d numbers", count)
+	return stream.SendAndClose(&calculatorpb.AverageResponse{
+		Result: float64(sum) / float64(count),
+	})
+}
+
+func (*Server) FindMaximum(stream calculatorpb.CalculatorService_FindMaximumServer) error {
+	var max int32
+	first := true
+
+	w := heartbeat.Watch(stream.Context(), func() (interface{}, error) { return stream.Recv() }, 0)
+
+	for {
+		var req *calculatorpb.FindMaximumRequest
+		select {
+		case msg := <-w.Messages():
+			req = msg.(*calculatorpb.FindMaximumRequest)
+		case err := <-w.Err():
+			if err == io.EOF {
+				log.Println("Stream closed!")
+				return nil
+			}
+			log.Printf("Error receiving message from stream: %v\n", err)
+			return err
+		}
+
+		if hb := req.GetHeartbeat(); hb != nil {
+			if err := w.Pinged(hb.GetInterval()); err != nil {
+				return err
+			}
+			pong := &calculatorpb.Heartbeat{Id: hb.GetId(), Pong: true}
+			if err := stream.Send(&calculatorpb.FindMaximumResponse{Heartbeat: pong}); err != nil {
+				log.Printf("Error sending message to stream: %v", err)
+				return err
+			}
+			continue
+		}
+
+		number := req.GetNumber()
+
+		if first || number > max {
+			max, first = number, false
+			log.Printf("New max value updated: %v\n", max)
+
+			err := stream.Send(&calculatorpb.FindMaximumResponse{
+				Max: max,
+			})
+
+			if err == io.EOF {
+				log.Println("Stream closed!")
+				return nil
+			}
+			if err != nil {
+				log.Printf("Error sending message to stream: %v", err)
+				return err
+			}
+		}
+	}
+
+}
+
+func (s *Server) SquareRoot(ctx context.Context, req *calculatorpb.SquareRootRequest) (*calculatorpb.SquareRootResponse, error) {
+	log.Printf("Receiving call to SquareRoot with: %v\n", req)
+	number := req.GetNumber()
+
+	result, err := squareRoot(number)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	input := fmt.Sprintf("sqrt(%v)", number)
+	if err := s.record(ctx, "SquareRoot", input, result); err != nil {
+		return nil, err
+	}
+
+	response := &calculatorpb.SquareRootResponse{
+		Result: result,
+	}
+
+	return response, nil
+}
+
+// squareRoot is the domain check shared by SquareRoot and Evaluate.
+func squareRoot(number float64) (float64, error) {
+	if number < 0 {
+		return 0, fmt.Errorf("Received a negative number: %v", number)
+	}
+	return math.Sqrt(number), nil
+}
@@ -0,0 +1,224 @@
+package calculatorserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/expr"
+	"github.com/rsorage/grpc-go-course/calculator/session"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ansVar is the variable holding the last result of the session in
+// Evaluate.
+const ansVar = "ans"
+
+// defaultRegister is the memory register used when none is named.
+const defaultRegister = "m"
+
+// sessionError converts the errors of the session store.
+func sessionError(err error) error {
+	switch err {
+	case session.ErrNotFound:
+		return status.Error(codes.NotFound, "Session not found or expired")
+	case session.ErrTooManySessions:
+		return status.Error(codes.ResourceExhausted, "Too many open sessions, try again later")
+	case session.ErrMemoryFull:
+		return status.Errorf(codes.ResourceExhausted, "The session already holds %d registers", session.MaxRegisters)
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Errorf(codes.Internal, "Session store: %v", err)
+}
+
+// sessionID returns the session ID of the call, which is required.
+func sessionID(ctx context.Context) (string, error) {
+	id := session.FromContext(ctx)
+	if id == "" {
+		return "", status.Errorf(codes.FailedPrecondition, "No session: open one and send its ID in the %s metadata", session.MetadataKey)
+	}
+	return id, nil
+}
+
+// record adds a result to the history of the call's session, if it has
+// one. Retried and hedged attempts of a call are recorded once.
+func (s *Server) record(ctx context.Context, method, input string, result float64) error {
+	id := session.FromContext(ctx)
+	if id == "" {
+		return nil
+	}
+
+	err := s.sessions.Update(ctx, id, func(sess *session.Session) error {
+		sess.Record(session.Entry{
+			Method:    method,
+			Input:     input,
+			Result:    result,
+			Time:      time.Now(),
+			RequestID: session.RequestIDFromContext(ctx),
+		})
+		return nil
+	})
+	if err != nil {
+		return sessionError(err)
+	}
+	return nil
+}
+
+// sessionVars returns the memory registers and last result of the call's
+// session as expression variables, or nil if it has no session.
+func (s *Server) sessionVars(ctx context.Context) (map[string]float64, error) {
+	id := session.FromContext(ctx)
+	if id == "" {
+		return nil, nil
+	}
+
+	vars := make(map[string]float64)
+	err := s.sessions.Update(ctx, id, func(sess *session.Session) error {
+		for name, v := range sess.Memory {
+			vars[name] = v
+		}
+		if ans, ok := sess.Ans(); ok {
+			vars[ansVar] = ans
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return vars, nil
+}
+
+func (s *Server) OpenSession(ctx context.Context, req *calculatorpb.OpenSessionRequest) (*calculatorpb.OpenSessionResponse, error) {
+	sess, err := session.New()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Creating session: %v", err)
+	}
+	if err := s.sessions.Create(ctx, sess); err != nil {
+		return nil, sessionError(err)
+	}
+
+	return &calculatorpb.OpenSessionResponse{
+		SessionId: sess.ID,
+		IdleTtl:   durationpb.New(s.sessions.TTL()),
+	}, nil
+}
+
+func (s *Server) CloseSession(ctx context.Context, req *calculatorpb.CloseSessionRequest) (*calculatorpb.CloseSessionResponse, error) {
+	id, err := sessionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.Delete(ctx, id); err != nil {
+		return nil, sessionError(err)
+	}
+	return &calculatorpb.CloseSessionResponse{}, nil
+}
+
+func (s *Server) GetHistory(ctx context.Context, req *calculatorpb.GetHistoryRequest) (*calculatorpb.GetHistoryResponse, error) {
+	id, err := sessionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.GetLimit() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must not be negative, got %d", req.GetLimit())
+	}
+
+	res := &calculatorpb.GetHistoryResponse{}
+	err = s.sessions.Update(ctx, id, func(sess *session.Session) error {
+		history := sess.History
+		if limit := int(req.GetLimit()); limit > 0 && limit < len(history) {
+			history = history[len(history)-limit:]
+		}
+		for _, e := range history {
+			res.Entries = append(res.Entries, &calculatorpb.HistoryEntry{
+				Method: e.Method,
+				Input:  e.Input,
+				Result: e.Result,
+				Time:   timestamppb.New(e.Time),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return res, nil
+}
+
+// register validates the name of a memory register.
+func register(name string) (string, error) {
+	if name == "" {
+		return defaultRegister, nil
+	}
+	if len(name) > session.MaxRegisterName {
+		return "", status.Errorf(codes.InvalidArgument, "Register name must be at most %d bytes, got %d", session.MaxRegisterName, len(name))
+	}
+	if !expr.IsIdent(name) {
+		return "", status.Errorf(codes.InvalidArgument, "Register name must be an identifier, got %q", name)
+	}
+	if _, ok := expr.Constants[name]; ok || name == ansVar {
+		return "", status.Errorf(codes.InvalidArgument, "Register name %q is reserved", name)
+	}
+	return name, nil
+}
+
+func (s *Server) StoreMemory(ctx context.Context, req *calculatorpb.StoreMemoryRequest) (*calculatorpb.StoreMemoryResponse, error) {
+	id, err := sessionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err := register(req.GetRegister())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := req.GetValue().(*calculatorpb.StoreMemoryRequest_Number); !ok && !req.GetLastResult() {
+		return nil, status.Error(codes.InvalidArgument, "No value to store")
+	}
+
+	var value float64
+	err = s.sessions.Update(ctx, id, func(sess *session.Session) error {
+		value = req.GetNumber()
+		if req.GetLastResult() {
+			ans, ok := sess.Ans()
+			if !ok {
+				return status.Error(codes.FailedPrecondition, "The session has no result yet")
+			}
+			value = ans
+		}
+		return sess.Store(name, value)
+	})
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return &calculatorpb.StoreMemoryResponse{Value: value}, nil
+}
+
+func (s *Server) RecallMemory(ctx context.Context, req *calculatorpb.RecallMemoryRequest) (*calculatorpb.RecallMemoryResponse, error) {
+	id, err := sessionID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name, err := register(req.GetRegister())
+	if err != nil {
+		return nil, err
+	}
+
+	var value float64
+	err = s.sessions.Update(ctx, id, func(sess *session.Session) error {
+		v, ok := sess.Memory[name]
+		if !ok {
+			return status.Errorf(codes.NotFound, "Register %q is empty", name)
+		}
+		value = v
+		return nil
+	})
+	if err != nil {
+		return nil, sessionError(err)
+	}
+	return &calculatorpb.RecallMemoryResponse{Value: value}, nil
+}
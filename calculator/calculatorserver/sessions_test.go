@@ -0,0 +1,103 @@
+package calculatorserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/session"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestSessionLimits(t *testing.T) {
+	s := New(WithSessionStore(session.NewMemoryStore(0, 1)))
+	ctx := context.Background()
+
+	res, err := s.OpenSession(ctx, &calculatorpb.OpenSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.OpenSession(ctx, &calculatorpb.OpenSessionRequest{}); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("OpenSession() over the limit = %v, want %v", err, codes.ResourceExhausted)
+	}
+
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(session.MetadataKey, res.GetSessionId()))
+	store := func(name string) error {
+		_, err := s.StoreMemory(ctx, &calculatorpb.StoreMemoryRequest{
+			Register: name,
+			Value:    &calculatorpb.StoreMemoryRequest_Number{Number: 1},
+		})
+		return err
+	}
+
+	if err := store(strings.Repeat("x", session.MaxRegisterName+1)); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("StoreMemory() with a long name = %v, want %v", err, codes.InvalidArgument)
+	}
+	for i := 0; i < session.MaxRegisters; i++ {
+		if err := store("r" + strings.Repeat("x", i)); err != nil {
+			t.Fatalf("StoreMemory() #%d = %v", i, err)
+		}
+	}
+	if err := store("m"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("StoreMemory() of one register too many = %v, want %v", err, codes.ResourceExhausted)
+	}
+	if err := store("r"); err != nil {
+		t.Errorf("StoreMemory() overwriting a register = %v", err)
+	}
+}
+
+func TestHistoryBoundsInputs(t *testing.T) {
+	s := New()
+	res, err := s.OpenSession(context.Background(), &calculatorpb.OpenSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(session.MetadataKey, res.GetSessionId()))
+
+	expression := strings.Repeat(" ", 1<<20) + "1 + 1"
+	if _, err := s.Evaluate(ctx, &calculatorpb.EvaluateRequest{Expression: expression}); err != nil {
+		t.Fatal(err)
+	}
+	history, err := s.GetHistory(ctx, &calculatorpb.GetHistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history.GetEntries()[0]; len(got.GetInput()) > session.MaxInput || got.GetResult() != 2 {
+		t.Errorf("recorded an input of %d bytes with result %v", len(got.GetInput()), got.GetResult())
+	}
+}
+
+func TestHistoryRecordsAttemptsOnce(t *testing.T) {
+	s := New()
+	res, err := s.OpenSession(context.Background(), &calculatorpb.OpenSessionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(requestID string) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			session.MetadataKey, res.GetSessionId(),
+			session.RequestMetadataKey, requestID,
+		))
+		if _, err := s.Sum(ctx, &calculatorpb.SumRequest{A: 1, B: 2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a retried call and its hedged attempt, then another call
+	call("a")
+	call("a")
+	call("a")
+	call("b")
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(session.MetadataKey, res.GetSessionId()))
+	history, err := s.GetHistory(ctx, &calculatorpb.GetHistoryRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(history.GetEntries()); n != 2 {
+		t.Errorf("recorded %d entries, want 2", n)
+	}
+}
@@ -0,0 +1,88 @@
+package calculatorserver
+
+import (
+	"io"
+	"math"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/stats"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxCompression bounds the memory used by the t-digest of a Statistics
+// call.
+const maxCompression = 10000
+
+func (*Server) Statistics(stream calculatorpb.CalculatorService_StatisticsServer) error {
+	var (
+		summary     stats.Summary
+		percentiles []float64
+		compression float64
+		digest      *stats.TDigest
+		first       = true
+	)
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if opts := req.GetOptions(); opts != nil {
+			if !first {
+				return status.Error(codes.InvalidArgument, "Options are only allowed in the first message")
+			}
+			for _, p := range opts.GetPercentiles() {
+				if !(p >= 0 && p <= 100) {
+					return status.Errorf(codes.InvalidArgument, "Percentile must be between 0 and 100, got %v", p)
+				}
+			}
+			compression = opts.GetCompression()
+			if compression < 0 || compression > maxCompression {
+				return status.Errorf(codes.InvalidArgument, "Compression must be between 0 and %d, got %v", maxCompression, compression)
+			}
+			percentiles = opts.GetPercentiles()
+			first = false
+			continue
+		}
+		first = false
+
+		n := req.GetNumber()
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return status.Errorf(codes.InvalidArgument, "Number must be finite, got %v", n)
+		}
+
+		if digest == nil {
+			digest = stats.NewTDigest(compression)
+		}
+		summary.Add(n)
+		digest.Add(n)
+	}
+
+	if summary.Count() == 0 {
+		return status.Error(codes.InvalidArgument, "Cannot compute statistics of an empty stream")
+	}
+
+	res := &calculatorpb.StatisticsResponse{
+		Count:    summary.Count(),
+		Sum:      summary.Sum(),
+		Mean:     summary.Mean(),
+		Variance: summary.Variance(),
+		Stddev:   summary.StdDev(),
+		Min:      summary.Min(),
+		Max:      summary.Max(),
+		Median:   digest.Quantile(0.5),
+	}
+	for _, p := range percentiles {
+		res.Percentiles = append(res.Percentiles, &calculatorpb.Percentile{
+			Percentile: p,
+			Value:      digest.Quantile(p / 100),
+		})
+	}
+
+	return stream.SendAndClose(res)
+}
@@ -2,172 +2,90 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"io"
 	"log"
+	"os"
+	"os/signal"
 	"time"
 
 	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/rsorage/grpc-go-course/compression"
+	"github.com/rsorage/grpc-go-course/grpcclient"
+	"github.com/rsorage/grpc-go-course/heartbeat"
 )
 
-func main() {
-	cc, err := grpc.Dial("0.0.0.0:50052", grpc.WithInsecure())
-	if err != nil {
-		log.Fatalf("Failed to connect to server: %v", err)
-	}
+const usage = `Usage: calculator [flags] <command> [numbers...]
 
-	defer cc.Close()
+Commands:
+  sum <a> <b>        add two numbers
+  factor <n>         decompose a number into its prime factors
+  avg [numbers...]   average of the numbers
+  max [numbers...]   print the running maximum of the numbers
+  sqrt <x>           square root of a number
+  eval [name=value...] <expression>
+                     evaluate an arithmetic expression
+  repl               interactive mode
 
-	c := calculatorpb.NewCalculatorServiceClient(cc)
+When avg or max are given no numbers, they are read from stdin and
+streamed to the server as they arrive.
 
-	// doUnary(c)
-	// doServerStreaming(c)
-	// calcAverage(c)
-	doBiDiStreaming(c)
-	// calcSquareRoot(c)
-}
+Flags:
+`
 
-func doUnary(c calculatorpb.CalculatorServiceClient) {
-	request := &calculatorpb.SumRequest{
-		A: 5,
-		B: 6,
+func main() {
+	log.SetFlags(0)
+
+	addr := flag.String("addr", "localhost:50052", "CalculatorService address")
+	tls := flag.Bool("tls", false, "enable TLS")
+	caFile := flag.String("ca", "ssl/ca.crt", "CA trust certificate used with -tls")
+	hbInterval := flag.Duration("heartbeat", heartbeat.DefaultInterval, "ping interval of the max stream (0 disables)")
+	keepaliveTime := flag.Duration("keepalive", grpcclient.DefaultKeepalive.Time, "idle time after which the connection is pinged (0 disables)")
+	compressor := flag.String("compression", compression.Identity, "compressor of every call: identity, gzip, zstd or snappy")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	response, err := c.Sum(context.Background(), request)
-	if err != nil {
-		log.Fatalf("Unable to sum: %v", err)
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	fmt.Printf("The result is %d\n", response.Result)
-
-}
-
-func doServerStreaming(c calculatorpb.CalculatorServiceClient) {
-	request := &calculatorpb.DecomposePrimeNumberRequest{
-		Number: 120,
+	opts := []grpcclient.Option{
+		grpcclient.WithKeepalive(*keepaliveTime, grpcclient.DefaultKeepalive.Timeout, false),
+		grpcclient.WithCompression(*compressor),
 	}
-
-	stream, err := c.DecomposePrimeNumber(context.Background(), request)
-	if err != nil {
-		log.Fatalf("Impossible to open stream: %v", err)
-	}
-
-	for {
-		response, err := stream.Recv()
-		if err == io.EOF {
-			// Server closed stream
-			break
-		}
-		if err != nil {
-			log.Fatalf("Error while receiving stream: %v\n", err)
-		}
-
-		log.Printf("Factor received: %d\n", response.GetResult())
+	if *tls {
+		opts = append(opts, grpcclient.WithTLS(*caFile))
 	}
-}
-
-func calcAverage(c calculatorpb.CalculatorServiceClient) {
-	numbers := []int32{8, 56, 21, 27, 14}
 
-	stream, err := c.Average(context.Background())
+	cc, err := grpcclient.Dial(*addr, opts...)
 	if err != nil {
-		log.Fatalf("Error opening stream to calculate average: %v\n", err)
-	}
-
-	for _, num := range numbers {
-		stream.Send(&calculatorpb.AverageRequest{
-			Number: num,
-		})
+		log.Fatalf("Failed to connect to server: %v", err)
 	}
+	defer cc.Close()
 
-	res, err := stream.CloseAndRecv()
-	if err != nil {
-		log.Fatalf("Error closing stream: %v\n", err)
+	calc := &calculator{
+		c:         calculatorpb.NewCalculatorServiceClient(cc),
+		out:       os.Stdout,
+		heartbeat: *hbInterval,
 	}
 
-	log.Printf("Received average: %f", res.GetResult())
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-func doBiDiStreaming(c calculatorpb.CalculatorServiceClient) {
-
-	stream, err := c.FindMaximum(context.Background())
-	if err == io.EOF {
-		return
+	name, args := flag.Arg(0), flag.Args()[1:]
+	if name == "repl" {
+		err = calc.repl(ctx, os.Stdin)
+	} else {
+		err = calc.run(ctx, name, args, os.Stdin)
 	}
 	if err != nil {
-		return
+		log.Fatalf("%s: %v", name, err)
 	}
-
-	numbers := []int32{1, 5, 3, 6, 2, 20}
-
-	ch := make(chan struct{})
-
-	// Send stream
-	go func() {
-		for _, n := range numbers {
-			log.Printf("Sending number: %v\n", n)
-			err = stream.Send(&calculatorpb.FindMaximumRequest{
-				Number: n,
-			})
-
-			time.Sleep(200 * time.Millisecond)
-
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				log.Fatalf("Error sending msg to stream: %v", err)
-				break
-			}
-		}
-
-		stream.CloseSend()
-	}()
-
-	// Receive stream
-	go func() {
-		for {
-			res, err := stream.Recv()
-			if err == io.EOF {
-				break
-			}
-			if err != nil {
-				break
-			}
-
-			log.Printf("New max received: %v\n", res.GetMax())
-		}
-		close(ch)
-	}()
-
-	<-ch
 }
 
-func calcSquareRoot(c calculatorpb.CalculatorServiceClient) {
-	number := -8.0
-
-	request := &calculatorpb.SquareRootRequest{
-		Number: number,
-	}
-
-	response, err := c.SquareRoot(context.Background(), request)
-	if err != nil {
-		respErr, ok := status.FromError(err)
-		if ok {
-			// actual error from gRPC
-			log.Printf("Server sent --> %s", respErr.Message())
-			if respErr.Code() == codes.InvalidArgument {
-				log.Println("We probably sent a negative number!")
-			}
-			return
-		} else {
-			log.Fatalf("Error calculating square root: %v", err)
-			return
-		}
-	}
-
-	log.Printf("Square root of %f is %f\n", number, response.GetResult())
-}
+// callTimeout bounds the unary calls made by the CLI.
+const callTimeout = 10 * time.Second
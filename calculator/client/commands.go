@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/heartbeat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// calculator runs the CLI commands against a CalculatorService.
+type calculator struct {
+	c         calculatorpb.CalculatorServiceClient
+	out       io.Writer
+	heartbeat time.Duration
+}
+
+// run executes a single command. Streaming commands read their numbers
+// from in when no arguments are given.
+func (calc *calculator) run(ctx context.Context, name string, args []string, in io.Reader) error {
+	switch name {
+	case "sum":
+		return calc.sum(ctx, args)
+	case "factor":
+		return calc.factor(ctx, args)
+	case "avg":
+		return calc.average(ctx, args, in)
+	case "max":
+		return calc.max(ctx, args, in)
+	case "sqrt":
+		return calc.sqrt(ctx, args)
+	case "eval":
+		return calc.evaluate(ctx, args)
+	}
+	return fmt.Errorf("unknown command %q", name)
+}
+
+// numbers yields the arguments, or the whitespace separated words of in
+// when there are none, until ctx is done.
+func numbers(ctx context.Context, args []string, in io.Reader) <-chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+
+		next := func(s string) bool {
+			select {
+			case ch <- s:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if len(args) > 0 {
+			for _, a := range args {
+				if !next(a) {
+					return
+				}
+			}
+			return
+		}
+
+		scanner := bufio.NewScanner(in)
+		scanner.Split(bufio.ScanWords)
+		for scanner.Scan() {
+			if !next(scanner.Text()) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func parseInt32(s string) (int32, error) {
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return int32(n), nil
+}
+
+func (calc *calculator) sum(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: sum <a> <b>")
+	}
+	a, err := parseInt32(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := parseInt32(args[1])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.Sum(ctx, &calculatorpb.SumRequest{A: a, B: b})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(calc.out, res.GetResult())
+	return nil
+}
+
+func (calc *calculator) factor(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: factor <n>")
+	}
+	n, err := parseInt32(args[0])
+	if err != nil {
+		return err
+	}
+
+	stream, err := calc.c.DecomposePrimeNumber(ctx, &calculatorpb.DecomposePrimeNumberRequest{Number: n})
+	if err != nil {
+		return err
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(calc.out, res.GetResult())
+	}
+}
+
+func (calc *calculator) average(ctx context.Context, args []string, in io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := calc.c.Average(ctx)
+	if err != nil {
+		return err
+	}
+
+	for s := range numbers(ctx, args, in) {
+		n, err := parseInt32(s)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&calculatorpb.AverageRequest{Number: n}); err != nil {
+			break // the actual error is returned by CloseAndRecv
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(calc.out, res.GetResult())
+	return nil
+}
+
+func (calc *calculator) max(ctx context.Context, args []string, in io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := calc.openMax(ctx)
+	if err != nil {
+		return err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			res, err := stream.recv()
+			if err == io.EOF {
+				errCh <- nil
+				return
+			}
+			if err != nil {
+				errCh <- err
+				return
+			}
+			fmt.Fprintln(calc.out, res.GetMax())
+		}
+	}()
+
+	for s := range numbers(ctx, args, in) {
+		n, err := parseInt32(s)
+		if err != nil {
+			return err
+		}
+		if err := stream.send(n); err != nil {
+			break // the actual error is returned by Recv
+		}
+	}
+	stream.closeSend()
+
+	return <-errCh
+}
+
+// maxStream is a FindMaximum stream kept alive with heartbeats, unless they
+// are disabled with -heartbeat=0.
+type maxStream struct {
+	mu     sync.Mutex
+	stream calculatorpb.CalculatorService_FindMaximumClient
+	w      *heartbeat.Watcher
+}
+
+func (calc *calculator) openMax(ctx context.Context) (*maxStream, error) {
+	stream, err := calc.c.FindMaximum(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &maxStream{stream: stream}
+	recv := func() (interface{}, error) { return stream.Recv() }
+	if calc.heartbeat <= 0 {
+		s.w = heartbeat.Watch(ctx, recv, 0)
+		return s, nil
+	}
+
+	interval := durationpb.New(calc.heartbeat)
+	go heartbeat.Ping(ctx, calc.heartbeat, func(id int64) error {
+		return s.sendRequest(&calculatorpb.FindMaximumRequest{
+			Heartbeat: &calculatorpb.Heartbeat{Id: id, Interval: interval},
+		})
+	})
+	s.w = heartbeat.Watch(ctx, recv, heartbeat.Timeout(calc.heartbeat))
+	return s, nil
+}
+
+func (s *maxStream) send(n int32) error {
+	return s.sendRequest(&calculatorpb.FindMaximumRequest{Number: n})
+}
+
+func (s *maxStream) sendRequest(req *calculatorpb.FindMaximumRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.Send(req)
+}
+
+func (s *maxStream) closeSend() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stream.CloseSend()
+}
+
+// recv returns the next maximum, skipping pongs. It fails with UNAVAILABLE
+// if the server stops answering pings.
+func (s *maxStream) recv() (*calculatorpb.FindMaximumResponse, error) {
+	for {
+		select {
+		case msg := <-s.w.Messages():
+			if res := msg.(*calculatorpb.FindMaximumResponse); res.GetHeartbeat() == nil {
+				return res, nil
+			}
+		case err := <-s.w.Err():
+			return nil, err
+		}
+	}
+}
+
+func (calc *calculator) sqrt(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: sqrt <x>")
+	}
+	x, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", args[0])
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.SquareRoot(ctx, &calculatorpb.SquareRootRequest{Number: x})
+	if err != nil {
+		if status.Code(err) == codes.InvalidArgument {
+			return fmt.Errorf("%s", status.Convert(err).Message())
+		}
+		return err
+	}
+
+	fmt.Fprintln(calc.out, res.GetResult())
+	return nil
+}
+
+// evaluate evaluates the expression made of all the arguments. Variables
+// are bound with name=value arguments placed before it.
+func (calc *calculator) evaluate(ctx context.Context, args []string) error {
+	vars := make(map[string]float64)
+	for len(args) > 0 {
+		i := strings.Index(args[0], "=")
+		if i <= 0 {
+			break
+		}
+		v, err := strconv.ParseFloat(args[0][i+1:], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value of %s: %q", args[0][:i], args[0][i+1:])
+		}
+		vars[args[0][:i]] = v
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("usage: eval [name=value...] <expression>")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.Evaluate(ctx, &calculatorpb.EvaluateRequest{
+		Expression: strings.Join(args, " "),
+		Variables:  vars,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(calc.out, res.GetResult())
+	return nil
+}
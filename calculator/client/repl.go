@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/session"
+)
+
+const replHelp = `Commands:
+  sum <a> <b>      add two numbers
+  factor <n>       prime factors of n
+  avg <numbers...> average of the numbers
+  max <numbers...> send numbers to the running maximum stream
+  sqrt <x>         square root of x
+  eval <expr>      evaluate an arithmetic expression; "ans" is the last
+                   result and memory registers are variables
+  history [n]      the last n results, all by default
+  sto [register]   store the last result in a memory register ("m")
+  rcl [register]   print a memory register ("m")
+  help             show this help
+  quit             leave the REPL
+`
+
+// repl reads commands from in until it is exhausted or "quit" is entered.
+// A single FindMaximum stream stays open for the whole session, so the
+// maximum accumulates across "max" commands. Commands run in a calculator
+// session, which records their results.
+func (calc *calculator) repl(ctx context.Context, in io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sess, err := calc.c.OpenSession(ctx, &calculatorpb.OpenSessionRequest{})
+	if err != nil {
+		return fmt.Errorf("opening session: %v", err)
+	}
+	ctx = session.NewOutgoingContext(ctx, sess.GetSessionId())
+	defer calc.closeSession(sess.GetSessionId())
+
+	maxStream, err := calc.openMax(ctx)
+	if err != nil {
+		return err
+	}
+	defer maxStream.closeSend()
+
+	go func() {
+		for {
+			res, err := maxStream.recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					fmt.Fprintf(calc.out, "max stream closed: %v\n", err)
+				}
+				return
+			}
+			fmt.Fprintf(calc.out, "max = %d\n", res.GetMax())
+		}
+	}()
+
+	fmt.Fprint(calc.out, "Type 'help' for the list of commands.\n> ")
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 {
+			name, args := fields[0], fields[1:]
+
+			switch name {
+			case "quit", "exit":
+				return nil
+			case "help":
+				fmt.Fprint(calc.out, replHelp)
+			case "max":
+				err = sendMax(maxStream, args)
+			case "history":
+				err = calc.history(ctx, args)
+			case "sto":
+				err = calc.store(ctx, args)
+			case "rcl":
+				err = calc.recall(ctx, args)
+			case "avg":
+				if len(args) == 0 {
+					err = fmt.Errorf("usage: avg <numbers...>")
+				} else {
+					err = calc.average(ctx, args, nil)
+				}
+			default:
+				err = calc.run(ctx, name, args, nil)
+			}
+
+			if err != nil {
+				fmt.Fprintf(calc.out, "error: %v\n", err)
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		fmt.Fprint(calc.out, "> ")
+	}
+
+	return scanner.Err()
+}
+
+// closeSession closes the session id. It uses a context of its own, as the
+// one of the REPL is cancelled by the time it returns, e.g. on Ctrl-C.
+func (calc *calculator) closeSession(id string) {
+	ctx, cancel := context.WithTimeout(session.NewOutgoingContext(context.Background(), id), callTimeout)
+	defer cancel()
+
+	if _, err := calc.c.CloseSession(ctx, &calculatorpb.CloseSessionRequest{}); err != nil {
+		fmt.Fprintf(calc.out, "closing session: %v\n", err)
+	}
+}
+
+func sendMax(stream *maxStream, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: max <numbers...>")
+	}
+
+	var nums []int32
+	for _, a := range args {
+		n, err := parseInt32(a)
+		if err != nil {
+			return err
+		}
+		nums = append(nums, n)
+	}
+
+	for _, n := range nums {
+		if err := stream.send(n); err != nil {
+			return fmt.Errorf("max stream closed: %v", err)
+		}
+	}
+	return nil
+}
+
+func (calc *calculator) history(ctx context.Context, args []string) error {
+	limit := 0
+	if len(args) > 0 {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			return fmt.Errorf("usage: history [n]")
+		}
+		limit = n
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.GetHistory(ctx, &calculatorpb.GetHistoryRequest{Limit: int32(limit)})
+	if err != nil {
+		return err
+	}
+	for _, e := range res.GetEntries() {
+		fmt.Fprintf(calc.out, "%s  %s = %v\n", e.GetTime().AsTime().Local().Format("15:04:05"), e.GetInput(), e.GetResult())
+	}
+	return nil
+}
+
+// register returns the optional register argument of sto and rcl.
+func register(args []string) string {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return ""
+}
+
+func (calc *calculator) store(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.StoreMemory(ctx, &calculatorpb.StoreMemoryRequest{
+		Register: register(args),
+		Value:    &calculatorpb.StoreMemoryRequest_LastResult{LastResult: true},
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(calc.out, res.GetValue())
+	return nil
+}
+
+func (calc *calculator) recall(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	res, err := calc.c.RecallMemory(ctx, &calculatorpb.RecallMemoryRequest{Register: register(args)})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(calc.out, res.GetValue())
+	return nil
+}
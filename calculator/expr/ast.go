@@ -0,0 +1,48 @@
+// Package expr parses and evaluates infix arithmetic expressions such as
+// "2 * (x + 1) ^ 2 - sqrt(y) % 3".
+package expr
+
+// Node is a node of the parse tree.
+type Node interface {
+	// Pos is the byte offset of the node in the expression.
+	Pos() int
+}
+
+// Number is a numeric literal.
+type Number struct {
+	Offset int
+	Value  float64
+}
+
+// Var is a reference to a named variable or constant.
+type Var struct {
+	Offset int
+	Name   string
+}
+
+// Unary is a prefix operation: "-x" or "+x".
+type Unary struct {
+	Offset  int
+	Op      string
+	Operand Node
+}
+
+// Binary is an infix operation: one of + - * / % ^.
+type Binary struct {
+	Offset      int
+	Op          string
+	Left, Right Node
+}
+
+// Call is a function call.
+type Call struct {
+	Offset int
+	Name   string
+	Args   []Node
+}
+
+func (n *Number) Pos() int { return n.Offset }
+func (n *Var) Pos() int    { return n.Offset }
+func (n *Unary) Pos() int  { return n.Offset }
+func (n *Binary) Pos() int { return n.Offset }
+func (n *Call) Pos() int   { return n.Offset }
@@ -0,0 +1,223 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Func is a function callable from expressions. It returns an error when
+// its arguments are outside of its domain.
+type Func struct {
+	// MinArgs and MaxArgs bound the number of arguments; a negative
+	// MaxArgs means any number.
+	MinArgs, MaxArgs int
+	Fn               func(args []float64) (float64, error)
+}
+
+// UnaryFunc wraps a one-argument function.
+func UnaryFunc(fn func(float64) (float64, error)) Func {
+	return Func{MinArgs: 1, MaxArgs: 1, Fn: func(args []float64) (float64, error) {
+		return fn(args[0])
+	}}
+}
+
+func pure(fn func(float64) float64) Func {
+	return UnaryFunc(func(x float64) (float64, error) {
+		return fn(x), nil
+	})
+}
+
+// Env holds the variables and functions available to an expression.
+type Env struct {
+	Vars  map[string]float64
+	Funcs map[string]Func
+}
+
+// Constants are the variables predefined in every expression. Variables
+// bound in an Env take precedence over them.
+var Constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// Builtins returns the functions available by default. The map is a
+// copy, so entries may be overridden freely.
+func Builtins() map[string]Func {
+	return map[string]Func{
+		"sqrt": UnaryFunc(func(x float64) (float64, error) {
+			if x < 0 {
+				return 0, errors.New("square root of a negative number")
+			}
+			return math.Sqrt(x), nil
+		}),
+		"cbrt":  pure(math.Cbrt),
+		"abs":   pure(math.Abs),
+		"exp":   pure(math.Exp),
+		"ln":    UnaryFunc(logarithm(math.Log)),
+		"log10": UnaryFunc(logarithm(math.Log10)),
+		"log2":  UnaryFunc(logarithm(math.Log2)),
+		"log": {MinArgs: 1, MaxArgs: 2, Fn: func(args []float64) (float64, error) {
+			x, err := logarithm(math.Log)(args[0])
+			if err != nil || len(args) == 1 {
+				return x, err
+			}
+			base := args[1]
+			if base <= 0 || base == 1 {
+				return 0, errors.New("logarithm base must be positive and different from 1")
+			}
+			return x / math.Log(base), nil
+		}},
+		"sin":   pure(math.Sin),
+		"cos":   pure(math.Cos),
+		"tan":   pure(math.Tan),
+		"asin":  UnaryFunc(bounded(math.Asin)),
+		"acos":  UnaryFunc(bounded(math.Acos)),
+		"atan":  pure(math.Atan),
+		"floor": pure(math.Floor),
+		"ceil":  pure(math.Ceil),
+		"round": pure(math.Round),
+		"min": {MinArgs: 1, MaxArgs: -1, Fn: func(args []float64) (float64, error) {
+			m := args[0]
+			for _, a := range args[1:] {
+				m = math.Min(m, a)
+			}
+			return m, nil
+		}},
+		"max": {MinArgs: 1, MaxArgs: -1, Fn: func(args []float64) (float64, error) {
+			m := args[0]
+			for _, a := range args[1:] {
+				m = math.Max(m, a)
+			}
+			return m, nil
+		}},
+	}
+}
+
+func logarithm(fn func(float64) float64) func(float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		if x <= 0 {
+			return 0, errors.New("logarithm of a non-positive number")
+		}
+		return fn(x), nil
+	}
+}
+
+func bounded(fn func(float64) float64) func(float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		if x < -1 || x > 1 {
+			return 0, errors.New("argument must be between -1 and 1")
+		}
+		return fn(x), nil
+	}
+}
+
+// Eval evaluates the parse tree. Errors are of type *Error and point at
+// the node that failed.
+func Eval(n Node, env Env) (float64, error) {
+	switch n := n.(type) {
+	case *Number:
+		return n.Value, nil
+
+	case *Var:
+		if v, ok := env.Vars[n.Name]; ok {
+			return v, nil
+		}
+		if v, ok := Constants[n.Name]; ok {
+			return v, nil
+		}
+		return 0, errorf(n.Offset, "undefined variable %q", n.Name)
+
+	case *Unary:
+		x, err := Eval(n.Operand, env)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == "-" {
+			return -x, nil
+		}
+		return x, nil
+
+	case *Binary:
+		return evalBinary(n, env)
+
+	case *Call:
+		return evalCall(n, env)
+	}
+
+	return 0, errorf(n.Pos(), "unknown node %T", n)
+}
+
+func evalBinary(n *Binary, env Env) (float64, error) {
+	l, err := Eval(n.Left, env)
+	if err != nil {
+		return 0, err
+	}
+	r, err := Eval(n.Right, env)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.Op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, errorf(n.Offset, "division by zero")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return 0, errorf(n.Offset, "modulo by zero")
+		}
+		return math.Mod(l, r), nil
+	case "^":
+		v := math.Pow(l, r)
+		if math.IsNaN(v) {
+			return 0, errorf(n.Offset, "%v ^ %v is not a real number", l, r)
+		}
+		return v, nil
+	}
+
+	return 0, errorf(n.Offset, "unknown operator %q", n.Op)
+}
+
+func evalCall(n *Call, env Env) (float64, error) {
+	fn, ok := env.Funcs[n.Name]
+	if !ok {
+		return 0, errorf(n.Offset, "undefined function %q", n.Name)
+	}
+
+	if len(n.Args) < fn.MinArgs || (fn.MaxArgs >= 0 && len(n.Args) > fn.MaxArgs) {
+		return 0, errorf(n.Offset, "%s expects %s, got %d", n.Name, arity(fn), len(n.Args))
+	}
+
+	args := make([]float64, len(n.Args))
+	for i, a := range n.Args {
+		v, err := Eval(a, env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+
+	v, err := fn.Fn(args)
+	if err != nil {
+		return 0, errorf(n.Offset, "%s: %v", n.Name, err)
+	}
+	return v, nil
+}
+
+func arity(fn Func) string {
+	switch {
+	case fn.MaxArgs < 0:
+		return fmt.Sprintf("at least %d argument(s)", fn.MinArgs)
+	case fn.MinArgs == fn.MaxArgs:
+		return fmt.Sprintf("%d argument(s)", fn.MinArgs)
+	}
+	return fmt.Sprintf("%d to %d arguments", fn.MinArgs, fn.MaxArgs)
+}
@@ -0,0 +1,326 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Error is a syntax or evaluation error at a position of the expression.
+type Error struct {
+	// Pos is the byte offset of the error in the expression.
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+func errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+			continue
+		case r >= '0' && r <= '9' || r == '.':
+			i = scanNumber(src, i)
+			tokens = append(tokens, token{tokNumber, start, src[start:i]})
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) {
+				r, size := utf8.DecodeRuneInString(src[i:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			tokens = append(tokens, token{tokIdent, start, src[start:i]})
+			continue
+		}
+
+		var kind tokenKind
+		switch r {
+		case '+', '-', '*', '/', '%', '^':
+			kind = tokOp
+		case '(':
+			kind = tokLParen
+		case ')':
+			kind = tokRParen
+		case ',':
+			kind = tokComma
+		default:
+			return nil, errorf(start, "unexpected character %q", r)
+		}
+		i += size
+		tokens = append(tokens, token{kind, start, src[start:i]})
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// scanNumber returns the end of the number starting at i: digits with an
+// optional fraction and exponent.
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// MaxDepth bounds the nesting of parentheses, calls and operators, so that
+// deeply nested expressions fail to parse rather than exhaust the stack.
+// Every operator of a chain such as 1 + 2 + 3 nests the previous ones and
+// counts as a level.
+const MaxDepth = 256
+
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+// Parse parses an expression. Operators have the usual precedence, from
+// lowest to highest: + -, then * / %, then unary minus, then ^ which is
+// right associative. Expressions may nest up to MaxDepth levels.
+func Parse(src string) (Node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "unexpected %v", t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isOp(ops ...string) bool {
+	t := p.peek()
+	if t.kind != tokOp {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+// expr = term { ("+" | "-") term }
+func (p *parser) expr() (Node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+
+	depth := p.depth
+	defer func() { p.depth = depth }()
+	for p.isOp("+", "-") {
+		op := p.next()
+		if p.depth++; p.depth > MaxDepth {
+			return nil, errorf(op.pos, "expression nested deeper than %d levels", MaxDepth)
+		}
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Offset: op.pos, Op: op.text, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// term = unary { ("*" | "/" | "%") unary }
+func (p *parser) term() (Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	depth := p.depth
+	defer func() { p.depth = depth }()
+	for p.isOp("*", "/", "%") {
+		op := p.next()
+		if p.depth++; p.depth > MaxDepth {
+			return nil, errorf(op.pos, "expression nested deeper than %d levels", MaxDepth)
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{Offset: op.pos, Op: op.text, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// unary = ("-" | "+") unary | power
+func (p *parser) unary() (Node, error) {
+	// every recursion of the grammar goes through unary
+	if p.depth++; p.depth > MaxDepth {
+		return nil, errorf(p.peek().pos, "expression nested deeper than %d levels", MaxDepth)
+	}
+	defer func() { p.depth-- }()
+
+	if p.isOp("-", "+") {
+		op := p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Offset: op.pos, Op: op.text, Operand: operand}, nil
+	}
+	return p.power()
+}
+
+// power = primary [ "^" unary ]
+func (p *parser) power() (Node, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.isOp("^") {
+		op := p.next()
+		exp, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Binary{Offset: op.pos, Op: op.text, Left: base, Right: exp}, nil
+	}
+	return base, nil
+}
+
+// primary = number | ident | ident "(" [ expr { "," expr } ] ")" | "(" expr ")"
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, errorf(t.pos, "invalid number %q", t.text)
+		}
+		return &Number{Offset: t.pos, Value: v}, nil
+
+	case tokIdent:
+		if p.peek().kind != tokLParen {
+			return &Var{Offset: t.pos, Name: t.text}, nil
+		}
+		p.next()
+
+		call := &Call{Offset: t.pos, Name: t.text}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			call.Args = append(call.Args, arg)
+
+			switch sep := p.next(); sep.kind {
+			case tokComma:
+				continue
+			case tokRParen:
+				return call, nil
+			default:
+				return nil, errorf(sep.pos, "expected \",\" or \")\" in call to %s, got %v", call.Name, sep)
+			}
+		}
+
+	case tokLParen:
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, errorf(closing.pos, "expected \")\" to close \"(\" at position %d, got %v", t.pos, closing)
+		}
+		return n, nil
+	}
+
+	return nil, errorf(t.pos, "unexpected %v", t)
+}
+
+// IsIdent reports whether s is a valid variable or function name.
+func IsIdent(s string) bool {
+	for i, r := range s {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,175 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// show renders n fully parenthesized.
+func show(n Node) string {
+	switch n := n.(type) {
+	case *Number:
+		return fmt.Sprint(n.Value)
+	case *Var:
+		return n.Name
+	case *Unary:
+		return "(" + n.Op + show(n.Operand) + ")"
+	case *Binary:
+		return "(" + show(n.Left) + " " + n.Op + " " + show(n.Right) + ")"
+	case *Call:
+		args := make([]string, len(n.Args))
+		for i, a := range n.Args {
+			args[i] = show(a)
+		}
+		return n.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"8 / 4 / 2", "((8 / 4) / 2)"},
+		{"7 % 4 * 2", "((7 % 4) * 2)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"-2 ^ 2", "(-(2 ^ 2))"},
+		{"2 ^ -1", "(2 ^ (-1))"},
+		{"--x", "(-(-x))"},
+		{"-x * y", "((-x) * y)"},
+		{"2 * (x + 1) ^ 2", "(2 * ((x + 1) ^ 2))"},
+		{"max(1, 2 + 3, f())", "max(1, (2 + 3), f())"},
+		{"1.5e3 + .5 + 2E-2", "((1500 + 0.5) + 0.02)"},
+		{"é_1 + _x", "(é_1 + _x)"},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q) = %v", tt.src, err)
+			continue
+		}
+		if got := show(n); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	deep := strings.Repeat("(", MaxDepth) + "1" + strings.Repeat(")", MaxDepth)
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{"", 0, "unexpected end of expression"},
+		{"1 +", 3, "unexpected end of expression"},
+		{"1 + * 2", 4, `unexpected "*"`},
+		{"1 2", 2, `unexpected "2"`},
+		{"(1 + 2", 6, `expected ")" to close "(" at position 0, got end of expression`},
+		{"f(1; 2)", 3, `unexpected character ';'`},
+		{"f(1 2)", 4, `expected "," or ")" in call to f, got "2"`},
+		{"1.2.3", 3, `unexpected ".3"`},
+		{"x + .", 4, `invalid number "."`},
+		{"2 ** 3", 3, `unexpected "*"`},
+		{deep, MaxDepth, fmt.Sprintf("expression nested deeper than %d levels", MaxDepth)},
+		{strings.Repeat("-", 1<<20) + "1", MaxDepth, fmt.Sprintf("expression nested deeper than %d levels", MaxDepth)},
+		{strings.Repeat("1+", 1<<20) + "1", 2 * MaxDepth, fmt.Sprintf("expression nested deeper than %d levels", MaxDepth)},
+		{strings.Repeat("1*", 1<<20) + "1", 2 * MaxDepth, fmt.Sprintf("expression nested deeper than %d levels", MaxDepth)},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Parse(%.20q) = %v, want an *Error", tt.src, err)
+			continue
+		}
+		if e.Pos != tt.pos || e.Msg != tt.msg {
+			t.Errorf("Parse(%.20q) = %d: %s, want %d: %s", tt.src, e.Pos, e.Msg, tt.pos, tt.msg)
+		}
+	}
+
+	if _, err := Parse(deep[1 : len(deep)-1]); err != nil {
+		t.Errorf("Parse() nested %d levels = %v", MaxDepth, err)
+	}
+	if _, err := Parse(strings.Repeat("1+", MaxDepth-1) + "1"); err != nil {
+		t.Errorf("Parse() of %d chained operators = %v", MaxDepth-1, err)
+	}
+}
+
+func TestEval(t *testing.T) {
+	env := Env{Vars: map[string]float64{"x": 3, "pi": 4}, Funcs: Builtins()}
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"7 % 4", 3},
+		{"-7 % 4", -3},
+		{"x * 2", 6},
+		{"pi", 4}, // variables shadow constants
+		{"e", math.E},
+		{"log(8, 2)", 3},
+		{"max(1, x, 2)", 3},
+		{"sqrt(16) + abs(-1)", 5},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.src, err)
+		}
+		got, err := Eval(n, env)
+		if err != nil || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Eval(%q) = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := Env{Funcs: Builtins()}
+	tests := []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{"1 + y", 4, `undefined variable "y"`},
+		{"2 * 3 / (x - x)", 9, `undefined variable "x"`},
+		{"1 / (2 - 2)", 2, "division by zero"},
+		{"5 % 0", 2, "modulo by zero"},
+		{"1 + (-8) ^ 0.5", 9, "-8 ^ 0.5 is not a real number"},
+		{"1 + sqrt(-1)", 4, "sqrt: square root of a negative number"},
+		{"log(2, 1)", 0, "log: logarithm base must be positive and different from 1"},
+		{"min()", 0, "min expects at least 1 argument(s), got 0"},
+		{"sin(1, 2)", 0, "sin expects 1 argument(s), got 2"},
+		{"2 * nope(1)", 4, `undefined function "nope"`},
+	}
+	for _, tt := range tests {
+		n, err := Parse(tt.src)
+		if err != nil {
+			t.Fatalf("Parse(%q) = %v", tt.src, err)
+		}
+		_, err = Eval(n, env)
+		var e *Error
+		if !errors.As(err, &e) {
+			t.Errorf("Eval(%q) = %v, want an *Error", tt.src, err)
+			continue
+		}
+		if e.Pos != tt.pos || e.Msg != tt.msg {
+			t.Errorf("Eval(%q) = %d: %s, want %d: %s", tt.src, e.Pos, e.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestIsIdent(t *testing.T) {
+	for s, want := range map[string]bool{"x": true, "x1": true, "_": true, "é": true, "": false, "1x": false, "a-b": false} {
+		if got := IsIdent(s); got != want {
+			t.Errorf("IsIdent(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,411 @@
+// Package linalg implements dense vector and matrix operations.
+package linalg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrDimension is returned when the dimensions of the operands do not
+	// suit the operation.
+	ErrDimension = errors.New("dimension mismatch")
+	// ErrSingular is returned when a matrix that must be invertible is not.
+	ErrSingular = errors.New("matrix is singular")
+)
+
+// Matrix is a dense matrix of float64 stored in row-major order.
+type Matrix struct {
+	rows, cols int
+	data       []float64
+}
+
+// New creates a rows×cols zero matrix.
+func New(rows, cols int) *Matrix {
+	return &Matrix{rows: rows, cols: cols, data: make([]float64, rows*cols)}
+}
+
+// FromRows creates a matrix from its rows, which must all have the same
+// non-zero length.
+func FromRows(rows [][]float64) (*Matrix, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, fmt.Errorf("%w: empty matrix", ErrDimension)
+	}
+	m := New(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != m.cols {
+			return nil, fmt.Errorf("%w: row %d has %d columns, row 0 has %d", ErrDimension, i, len(row), m.cols)
+		}
+		copy(m.data[i*m.cols:], row)
+	}
+	return m, nil
+}
+
+// Identity creates the n×n identity matrix.
+func Identity(n int) *Matrix {
+	m := New(n, n)
+	for i := 0; i < n; i++ {
+		m.Set(i, i, 1)
+	}
+	return m
+}
+
+// Dims returns the number of rows and columns of m.
+func (m *Matrix) Dims() (rows, cols int) {
+	return m.rows, m.cols
+}
+
+// At returns the element at row i and column j.
+func (m *Matrix) At(i, j int) float64 {
+	return m.data[i*m.cols+j]
+}
+
+// Set sets the element at row i and column j.
+func (m *Matrix) Set(i, j int, v float64) {
+	m.data[i*m.cols+j] = v
+}
+
+// Row returns row i. It shares its elements with m.
+func (m *Matrix) Row(i int) []float64 {
+	return m.data[i*m.cols : (i+1)*m.cols]
+}
+
+// Rows returns a copy of the rows of m.
+func (m *Matrix) Rows() [][]float64 {
+	rows := make([][]float64, m.rows)
+	for i := range rows {
+		rows[i] = append([]float64(nil), m.Row(i)...)
+	}
+	return rows
+}
+
+func (m *Matrix) clone() *Matrix {
+	return &Matrix{rows: m.rows, cols: m.cols, data: append([]float64(nil), m.data...)}
+}
+
+// Mul returns the product a×b.
+func Mul(a, b *Matrix) (*Matrix, error) {
+	if a.cols != b.rows {
+		return nil, fmt.Errorf("%w: cannot multiply %d×%d by %d×%d", ErrDimension, a.rows, a.cols, b.rows, b.cols)
+	}
+	c := New(a.rows, b.cols)
+	for i := 0; i < a.rows; i++ {
+		mulRow(c.Row(i), a.Row(i), b)
+	}
+	return c, nil
+}
+
+// MulRow returns the product of the row vector row by b, which is row i of
+// a×b when row is row i of a.
+func MulRow(row []float64, b *Matrix) ([]float64, error) {
+	if len(row) != b.rows {
+		return nil, fmt.Errorf("%w: cannot multiply a row of %d elements by %d×%d", ErrDimension, len(row), b.rows, b.cols)
+	}
+	dst := make([]float64, b.cols)
+	mulRow(dst, row, b)
+	return dst, nil
+}
+
+func mulRow(dst, row []float64, b *Matrix) {
+	// iterating over the rows of b keeps the accesses sequential
+	for k, v := range row {
+		if v == 0 {
+			continue
+		}
+		for j, w := range b.Row(k) {
+			dst[j] += v * w
+		}
+	}
+}
+
+// Transpose returns the transpose of m.
+func Transpose(m *Matrix) *Matrix {
+	t := New(m.cols, m.rows)
+	for i := 0; i < m.rows; i++ {
+		for j := 0; j < m.cols; j++ {
+			t.Set(j, i, m.At(i, j))
+		}
+	}
+	return t
+}
+
+// lu is an LU decomposition with partial pivoting: the rows of a permuted
+// by perm equal l×u, both stored in lu. A zero column left after the
+// previous eliminations gives a zero pivot, making the matrix singular.
+type lu struct {
+	lu       *Matrix
+	perm     []int
+	sign     float64 // of the permutation
+	singular bool
+}
+
+// MaxCondition is the largest condition number of the matrices Inverse and
+// Solve accept, the reciprocal of the float64 machine epsilon. A condition
+// number of c loses about log10(c) of the 16 significant digits of float64,
+// so beyond it rounding errors may exceed the results.
+const MaxCondition = 1 << 52
+
+func decompose(m *Matrix) (*lu, error) {
+	if m.rows != m.cols {
+		return nil, fmt.Errorf("%w: %d×%d matrix is not square", ErrDimension, m.rows, m.cols)
+	}
+	n := m.rows
+	d := &lu{lu: m.clone(), perm: make([]int, n), sign: 1}
+	a := d.lu
+
+	for i := range d.perm {
+		d.perm[i] = i
+	}
+
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a.At(i, k)) > math.Abs(a.At(p, k)) {
+				p = i
+			}
+		}
+		if a.At(p, k) == 0 {
+			d.singular = true
+			continue
+		}
+		if p != k {
+			rp, rk := a.Row(p), a.Row(k)
+			for j := range rk {
+				rp[j], rk[j] = rk[j], rp[j]
+			}
+			d.perm[p], d.perm[k] = d.perm[k], d.perm[p]
+			d.sign = -d.sign
+		}
+
+		pivot := a.At(k, k)
+		for i := k + 1; i < n; i++ {
+			f := a.At(i, k) / pivot
+			a.Set(i, k, f)
+			ri, rk := a.Row(i), a.Row(k)
+			for j := k + 1; j < n; j++ {
+				ri[j] -= f * rk[j]
+			}
+		}
+	}
+	return d, nil
+}
+
+// solve solves l×u×x = b for x, b being already permuted.
+func (d *lu) solve(b []float64) []float64 {
+	a, n := d.lu, d.lu.rows
+	x := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x[i] = b[d.perm[i]]
+		for j := 0; j < i; j++ {
+			x[i] -= a.At(i, j) * x[j]
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			x[i] -= a.At(i, j) * x[j]
+		}
+		x[i] /= a.At(i, i)
+	}
+	return x
+}
+
+// solveTransposed solves (l×u)ᵀ×x = b for x, x being permuted back.
+func (d *lu) solveTransposed(b []float64) []float64 {
+	a, n := d.lu, d.lu.rows
+	y := make([]float64, n)
+	for i := 0; i < n; i++ {
+		y[i] = b[i]
+		for j := 0; j < i; j++ {
+			y[i] -= a.At(j, i) * y[j]
+		}
+		y[i] /= a.At(i, i)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := i + 1; j < n; j++ {
+			y[i] -= a.At(j, i) * y[j]
+		}
+	}
+	x := make([]float64, n)
+	for i, v := range y {
+		x[d.perm[i]] = v
+	}
+	return x
+}
+
+// inverseNorm1 estimates the 1-norm of the inverse of the decomposed
+// matrix without computing it, with Hager's method as refined by Higham
+// (ACM TOMS 14(4), 1988). The estimate never exceeds the norm and is
+// usually exact.
+func (d *lu) inverseNorm1() float64 {
+	n := d.lu.rows
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = 1 / float64(n)
+	}
+
+	est := 0.0
+	var signs []float64
+	for iter := 0; iter < 5; iter++ {
+		y := d.solve(x)
+		norm := 0.0
+		for _, v := range y {
+			norm += math.Abs(v)
+		}
+		if iter > 0 && norm <= est {
+			break
+		}
+		est = norm
+
+		s := make([]float64, n)
+		for i, v := range y {
+			s[i] = 1
+			if v < 0 {
+				s[i] = -1
+			}
+		}
+		if signs != nil && equal(s, signs) {
+			break
+		}
+		signs = s
+
+		z := d.solveTransposed(s)
+		j, zx := 0, 0.0
+		for i, v := range z {
+			zx += v * x[i]
+			if math.Abs(v) > math.Abs(z[j]) {
+				j = i
+			}
+		}
+		if iter > 0 && math.Abs(z[j]) <= zx {
+			break
+		}
+		for i := range x {
+			x[i] = 0
+		}
+		x[j] = 1
+	}
+
+	// the alternating vector catches the matrices that fool the iteration
+	for i := range x {
+		x[i] = 1 + float64(i)/math.Max(1, float64(n-1))
+		if i%2 == 1 {
+			x[i] = -x[i]
+		}
+	}
+	alt := 0.0
+	for _, v := range d.solve(x) {
+		alt += math.Abs(v)
+	}
+	return math.Max(est, 2*alt/float64(3*n))
+}
+
+func equal(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkCondition fails with ErrSingular if the condition number cond is
+// over MaxCondition.
+func checkCondition(cond float64) error {
+	if !(cond <= MaxCondition) {
+		return fmt.Errorf("%w: condition number %.3g is over %.3g", ErrSingular, cond, float64(MaxCondition))
+	}
+	return nil
+}
+
+// inverse returns the inverse of the decomposed matrix m and its condition
+// number in the 1-norm. It fails with ErrSingular if m is singular or the
+// condition number is over MaxCondition.
+func (d *lu) inverse(m *Matrix) (*Matrix, float64, error) {
+	if d.singular {
+		return nil, math.Inf(1), ErrSingular
+	}
+
+	n := m.rows
+	inv := New(n, n)
+	e := make([]float64, n)
+	for j := 0; j < n; j++ {
+		for i := range e {
+			e[i] = 0
+		}
+		e[j] = 1
+		for i, v := range d.solve(e) {
+			inv.Set(i, j, v)
+		}
+	}
+
+	cond := norm1(m) * norm1(inv)
+	if err := checkCondition(cond); err != nil {
+		return nil, cond, err
+	}
+	return inv, cond, nil
+}
+
+// norm1 returns the largest sum of the absolute values of a column of m.
+func norm1(m *Matrix) float64 {
+	sums := make([]float64, m.cols)
+	for i := 0; i < m.rows; i++ {
+		for j, v := range m.Row(i) {
+			sums[j] += math.Abs(v)
+		}
+	}
+	norm := 0.0
+	for _, s := range sums {
+		norm = math.Max(norm, s)
+	}
+	return norm
+}
+
+// Det returns the determinant of the square matrix m, the product of the
+// pivots of its LU decomposition. It is zero only if a pivot is.
+func Det(m *Matrix) (float64, error) {
+	d, err := decompose(m)
+	if err != nil {
+		return 0, err
+	}
+	if d.singular {
+		return 0, nil
+	}
+	det := d.sign
+	for i := 0; i < m.rows; i++ {
+		det *= d.lu.At(i, i)
+	}
+	return det, nil
+}
+
+// Inverse returns the inverse of the square matrix m and its condition
+// number in the 1-norm. It fails with ErrSingular if m is singular or too
+// ill-conditioned for the inverse to be meaningful, see MaxCondition.
+func Inverse(m *Matrix) (*Matrix, float64, error) {
+	d, err := decompose(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	return d.inverse(m)
+}
+
+// Solve returns x such that a×x = b and the condition number of a in the
+// 1-norm, estimated without inverting a. It fails with ErrSingular like
+// Inverse.
+func Solve(a *Matrix, b []float64) ([]float64, float64, error) {
+	if a.rows != len(b) {
+		return nil, 0, fmt.Errorf("%w: %d×%d matrix and vector of %d elements", ErrDimension, a.rows, a.cols, len(b))
+	}
+	d, err := decompose(a)
+	if err != nil {
+		return nil, 0, err
+	}
+	if d.singular {
+		return nil, math.Inf(1), ErrSingular
+	}
+	cond := norm1(a) * d.inverseNorm1()
+	if err := checkCondition(cond); err != nil {
+		return nil, cond, err
+	}
+	return d.solve(b), cond, nil
+}
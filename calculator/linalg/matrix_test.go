@@ -0,0 +1,181 @@
+package linalg
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func mustRows(t *testing.T, rows [][]float64) *Matrix {
+	t.Helper()
+	m, err := FromRows(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func hilbert(n int) [][]float64 {
+	rows := make([][]float64, n)
+	for i := range rows {
+		rows[i] = make([]float64, n)
+		for j := range rows[i] {
+			rows[i][j] = 1 / float64(i+j+1)
+		}
+	}
+	return rows
+}
+
+func TestDet(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+		want float64
+	}{
+		{"tiny diagonal", [][]float64{{1e-13, 0}, {0, 1}}, 1e-13},
+		{"tiny scale", [][]float64{{1e-200, 0}, {0, 1e-200}}, 0}, // underflows
+		{"swap", [][]float64{{0, 1}, {1, 0}}, -1},
+		{"two swaps", [][]float64{{0, 1, 0}, {0, 0, 1}, {1, 0, 0}}, 1},
+		{"pivoting", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}}, -3},
+		{"singular", [][]float64{{1, 2}, {2, 4}}, 0},
+		{"zero column", [][]float64{{0, 1}, {0, 2}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Det(mustRows(t, tt.rows))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-12*math.Max(1, math.Abs(tt.want)) {
+				t.Errorf("Det(%v) = %v, want %v", tt.rows, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Det(New(2, 3)); !errors.Is(err, ErrDimension) {
+		t.Errorf("Det of a 2×3 matrix = %v, want ErrDimension", err)
+	}
+}
+
+func TestInverse(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+		ok   bool
+	}{
+		{"tiny diagonal", [][]float64{{1e-13, 0}, {0, 1}}, true},
+		{"uniformly tiny", [][]float64{{1e-20, 2e-20}, {3e-20, 4e-20}}, true},
+		{"swap", [][]float64{{0, 1}, {1, 0}}, true},
+		{"pivoting", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}}, true},
+		{"hilbert 8", hilbert(8), true},
+		{"singular", [][]float64{{1, 2}, {2, 4}}, false},
+		{"rounded singular", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, false},
+		{"hilbert 14", hilbert(14), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustRows(t, tt.rows)
+			inv, cond, err := Inverse(m)
+			if !tt.ok {
+				if !errors.Is(err, ErrSingular) {
+					t.Errorf("Inverse() = %v, want ErrSingular", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Inverse() = %v (condition %g)", err, cond)
+			}
+			if cond < 1 {
+				t.Errorf("condition number %g < 1", cond)
+			}
+			p, err := Mul(m, inv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, _ := m.Dims()
+			for i := 0; i < n; i++ {
+				for j := 0; j < n; j++ {
+					want := 0.0
+					if i == j {
+						want = 1
+					}
+					// the error grows with the condition number
+					if math.Abs(p.At(i, j)-want) > 1e-15*cond*float64(n) {
+						t.Errorf("(m×inverse)[%d][%d] = %v, want %v", i, j, p.At(i, j), want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSolve(t *testing.T) {
+	a := mustRows(t, [][]float64{{0, 2, 1}, {1, -1, 0}, {3, 0, 4}})
+	b := []float64{7, -1, 15}
+	x, _, err := Solve(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{1, 2, 3} {
+		if math.Abs(x[i]-want) > 1e-12 {
+			t.Errorf("x[%d] = %v, want %v", i, x[i], want)
+		}
+	}
+
+	if _, _, err := Solve(mustRows(t, hilbert(14)), make([]float64, 14)); !errors.Is(err, ErrSingular) {
+		t.Errorf("Solve with an ill-conditioned matrix = %v, want ErrSingular", err)
+	}
+	if _, _, err := Solve(a, []float64{1, 2}); !errors.Is(err, ErrDimension) {
+		t.Errorf("Solve with a short b = %v, want ErrDimension", err)
+	}
+	if _, _, err := Solve(mustRows(t, [][]float64{{1, 2}, {2, 4}}), []float64{1, 2}); !errors.Is(err, ErrSingular) {
+		t.Errorf("Solve with a singular matrix = %v, want ErrSingular", err)
+	}
+}
+
+func TestSolveTransposed(t *testing.T) {
+	a := mustRows(t, [][]float64{{0, 2, 1}, {1, -1, 0}, {3, 0, 4}})
+	d, err := decompose(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// aᵀ×{1, 2, 3} = {11, 0, 13}
+	x := d.solveTransposed([]float64{11, 0, 13})
+	for i, want := range []float64{1, 2, 3} {
+		if math.Abs(x[i]-want) > 1e-12 {
+			t.Errorf("x[%d] = %v, want %v", i, x[i], want)
+		}
+	}
+}
+
+func TestInverseNorm1(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]float64
+	}{
+		{"one", [][]float64{{4}}},
+		{"swap", [][]float64{{0, 1}, {1, 0}}},
+		{"pivoting", [][]float64{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}}},
+		{"mixed signs", [][]float64{{0, 2, 1}, {1, -1, 0}, {3, 0, 4}}},
+		{"hilbert 8", hilbert(8)},
+		{"hilbert 10", hilbert(10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mustRows(t, tt.rows)
+			d, err := decompose(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inv, _, err := d.inverse(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the estimate is a lower bound, exact for these matrices
+			want := norm1(inv)
+			if got := d.inverseNorm1(); got > want*(1+1e-9) || got < want*(1-1e-9) {
+				t.Errorf("inverseNorm1() = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package linalg
+
+import "fmt"
+
+// Dot returns the dot product of a and b.
+func Dot(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("%w: vectors of %d and %d elements", ErrDimension, len(a), len(b))
+	}
+	sum := 0.0
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum, nil
+}
+
+// Cross returns the cross product of the 3-dimensional vectors a and b.
+func Cross(a, b []float64) ([]float64, error) {
+	if len(a) != 3 || len(b) != 3 {
+		return nil, fmt.Errorf("%w: cross product needs 3-dimensional vectors, got %d and %d elements", ErrDimension, len(a), len(b))
+	}
+	return []float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}, nil
+}
@@ -0,0 +1,316 @@
+package prime
+
+import (
+	"context"
+	"math/big"
+)
+
+// Factor64 is a prime factor and its multiplicity.
+type Factor64 struct {
+	Prime    uint64
+	Exponent int
+}
+
+// FactorBig is a prime factor of a big integer and its multiplicity.
+type FactorBig struct {
+	Prime    *big.Int
+	Exponent int
+}
+
+// rhoCheckInterval is how many rho iterations run between context checks.
+const rhoCheckInterval = 1 << 12
+
+// Factorize64 calls emit with each prime factor of n and its multiplicity
+// as soon as it is found. Factors below 1000 are found by trial division
+// and emitted in ascending order, larger ones are found with Pollard's rho
+// and emitted in no particular order. It stops with the context's error
+// when ctx is done, or with the error returned by emit.
+func Factorize64(ctx context.Context, n uint64, emit func(Factor64) error) error {
+	if n < 2 {
+		return nil
+	}
+
+	for _, p := range smallPrimes {
+		if n%p != 0 {
+			continue
+		}
+		e := 0
+		for n%p == 0 {
+			n /= p
+			e++
+		}
+		if err := emit(Factor64{Prime: p, Exponent: e}); err != nil {
+			return err
+		}
+		if n == 1 {
+			return nil
+		}
+	}
+
+	for n > 1 {
+		p, err := primeFactor64(ctx, n)
+		if err != nil {
+			return err
+		}
+		e := 0
+		for n%p == 0 {
+			n /= p
+			e++
+		}
+		if err := emit(Factor64{Prime: p, Exponent: e}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// primeFactor64 returns a prime factor of n, which has no factor below
+// smallPrimeLimit.
+func primeFactor64(ctx context.Context, n uint64) (uint64, error) {
+	for !IsPrime64(n) {
+		d, err := rho64(ctx, n)
+		if err != nil {
+			return 0, err
+		}
+		// keep splitting the smaller side until a prime is left
+		if other := n / d; other < d {
+			d = other
+		}
+		n = d
+	}
+	return n, nil
+}
+
+func gcd64(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// rho64 finds a non-trivial factor of the odd composite n with Brent's
+// variant of Pollard's rho, trying increasing constants until it succeeds.
+func rho64(ctx context.Context, n uint64) (uint64, error) {
+	const m = 128
+
+	for c := uint64(1); ; c++ {
+		f := func(x uint64) uint64 {
+			return (mulMod(x, x, n) + c) % n
+		}
+
+		y, ys, x := uint64(2), uint64(2), uint64(2)
+		g, q := uint64(1), uint64(1)
+		iterations := 0
+
+		for r := 1; g == 1; r *= 2 {
+			x = y
+			for i := 0; i < r; i++ {
+				y = f(y)
+			}
+			for k := 0; k < r && g == 1; k += m {
+				ys = y
+				for i := 0; i < m && i < r-k; i++ {
+					y = f(y)
+					q = mulMod(q, absDiff(x, y), n)
+				}
+				g = gcd64(q, n)
+
+				if iterations += m; iterations >= rhoCheckInterval {
+					iterations = 0
+					if err := ctx.Err(); err != nil {
+						return 0, err
+					}
+				}
+			}
+		}
+
+		if g == n {
+			// the batch overshot: backtrack one step at a time
+			for g = 1; g == 1; {
+				ys = f(ys)
+				g = gcd64(absDiff(x, ys), n)
+			}
+		}
+		if g != n {
+			return g, nil
+		}
+	}
+}
+
+// FactorizeBig is Factorize64 for big integers. n is not modified.
+func FactorizeBig(ctx context.Context, n *big.Int, emit func(FactorBig) error) error {
+	if n.IsUint64() {
+		return Factorize64(ctx, n.Uint64(), func(f Factor64) error {
+			return emit(FactorBig{Prime: new(big.Int).SetUint64(f.Prime), Exponent: f.Exponent})
+		})
+	}
+	if n.Sign() <= 0 {
+		return nil
+	}
+
+	n = new(big.Int).Set(n)
+	q, r := new(big.Int), new(big.Int)
+
+	divideOut := func(p *big.Int) int {
+		e := 0
+		for {
+			q.QuoRem(n, p, r)
+			if r.Sign() != 0 {
+				return e
+			}
+			n.Set(q)
+			e++
+		}
+	}
+
+	for _, sp := range smallPrimes {
+		p := new(big.Int).SetUint64(sp)
+		if e := divideOut(p); e > 0 {
+			if err := emit(FactorBig{Prime: p, Exponent: e}); err != nil {
+				return err
+			}
+		}
+	}
+
+	one := big.NewInt(1)
+	for n.Cmp(one) > 0 {
+		// the rest fits in 64 bits: finish with the faster arithmetic
+		if n.IsUint64() {
+			return Factorize64(ctx, n.Uint64(), func(f Factor64) error {
+				return emit(FactorBig{Prime: new(big.Int).SetUint64(f.Prime), Exponent: f.Exponent})
+			})
+		}
+
+		p, err := primeFactorBig(ctx, n)
+		if err != nil {
+			return err
+		}
+		e := divideOut(p)
+		if err := emit(FactorBig{Prime: p, Exponent: e}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// primeFactorBig returns a prime factor of n, which has no factor below
+// smallPrimeLimit.
+func primeFactorBig(ctx context.Context, n *big.Int) (*big.Int, error) {
+	n = new(big.Int).Set(n)
+	for !IsPrimeBig(n) {
+		// rho takes about p^(1/2) steps to split the powers of a prime p
+		if r := perfectPowerRoot(n); r != nil {
+			n = r
+			continue
+		}
+		d, err := rhoBig(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		if other := new(big.Int).Quo(n, d); other.Cmp(d) < 0 {
+			d = other
+		}
+		n = d
+	}
+	return n, nil
+}
+
+// perfectPowerRoot returns r if n = r^k for some k >= 2, or nil. n has no
+// factor below smallPrimeLimit, so that r is above 2^9.
+func perfectPowerRoot(n *big.Int) *big.Int {
+	pow := new(big.Int)
+	for _, k := range smallPrimes {
+		if uint64(n.BitLen()) < 9*k {
+			break
+		}
+		r := iroot(n, k)
+		if pow.Exp(r, new(big.Int).SetUint64(k), nil).Cmp(n) == 0 {
+			return r
+		}
+	}
+	return nil
+}
+
+// iroot returns the floor of the kth root of n > 0, with Newton's method
+// starting above the root.
+func iroot(n *big.Int, k uint64) *big.Int {
+	if k == 2 {
+		return new(big.Int).Sqrt(n)
+	}
+	bk, km1 := new(big.Int).SetUint64(k), new(big.Int).SetUint64(k-1)
+	x := new(big.Int).Lsh(big.NewInt(1), uint((uint64(n.BitLen())+k-1)/k))
+	t := new(big.Int)
+	for {
+		// y = ((k-1)x + n/x^(k-1)) / k
+		t.Quo(n, t.Exp(x, km1, nil))
+		y := new(big.Int).Mul(x, km1)
+		y.Quo(y.Add(y, t), bk)
+		if y.Cmp(x) >= 0 {
+			return x
+		}
+		x = y
+	}
+}
+
+// rhoBig is rho64 for big integers.
+func rhoBig(ctx context.Context, n *big.Int) (*big.Int, error) {
+	const m = 128
+
+	for c := int64(1); ; c++ {
+		bc := big.NewInt(c)
+		f := func(x *big.Int) {
+			x.Mul(x, x)
+			x.Add(x, bc)
+			x.Mod(x, n)
+		}
+
+		y, ys, x := big.NewInt(2), big.NewInt(2), big.NewInt(2)
+		g, q := big.NewInt(1), big.NewInt(1)
+		diff := new(big.Int)
+		iterations := 0
+
+		for r := 1; isOne(g); r *= 2 {
+			x.Set(y)
+			for i := 0; i < r; i++ {
+				f(y)
+			}
+			for k := 0; k < r && isOne(g); k += m {
+				ys.Set(y)
+				for i := 0; i < m && i < r-k; i++ {
+					f(y)
+					q.Mul(q, diff.Abs(diff.Sub(x, y)))
+					q.Mod(q, n)
+				}
+				g.GCD(nil, nil, q, n)
+
+				if iterations += m; iterations >= rhoCheckInterval {
+					iterations = 0
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		if g.Cmp(n) == 0 {
+			for g.SetInt64(1); isOne(g); {
+				f(ys)
+				g.GCD(nil, nil, diff.Abs(diff.Sub(x, ys)), n)
+			}
+		}
+		if g.Cmp(n) != 0 {
+			return g, nil
+		}
+	}
+}
+
+func isOne(x *big.Int) bool {
+	return x.IsInt64() && x.Int64() == 1
+}
@@ -0,0 +1,107 @@
+// Package prime implements primality testing and integer factorization for
+// 64-bit and arbitrary-precision integers.
+package prime
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// smallPrimeLimit bounds the primes used for trial division.
+const smallPrimeLimit = 1000
+
+// smallPrimes are the primes below smallPrimeLimit, in ascending order.
+var smallPrimes = func() []uint64 {
+	composite := make([]bool, smallPrimeLimit)
+	var primes []uint64
+	for i := 2; i < smallPrimeLimit; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, uint64(i))
+		for j := i * i; j < smallPrimeLimit; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// millerRabinBases make Miller–Rabin deterministic for every 64-bit
+// integer.
+var millerRabinBases = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+func mulMod(a, b, m uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return bits.Rem64(hi, lo, m)
+}
+
+func powMod(base, exp, m uint64) uint64 {
+	result := uint64(1)
+	base %= m
+	for exp > 0 {
+		if exp&1 == 1 {
+			result = mulMod(result, base, m)
+		}
+		base = mulMod(base, base, m)
+		exp >>= 1
+	}
+	return result
+}
+
+// IsPrime64 reports whether n is prime. The test is deterministic.
+func IsPrime64(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for _, p := range smallPrimes {
+		if n == p {
+			return true
+		}
+		if n%p == 0 {
+			return false
+		}
+	}
+	if n < smallPrimeLimit*smallPrimeLimit {
+		return true
+	}
+
+	// n - 1 = d * 2^s with d odd
+	d := n - 1
+	s := bits.TrailingZeros64(d)
+	d >>= uint(s)
+
+	for _, a := range millerRabinBases {
+		x := powMod(a, d, n)
+		if x == 1 || x == n-1 {
+			continue
+		}
+		composite := true
+		for i := 1; i < s; i++ {
+			x = mulMod(x, x, n)
+			if x == n-1 {
+				composite = false
+				break
+			}
+		}
+		if composite {
+			return false
+		}
+	}
+	return true
+}
+
+// millerRabinRounds is the number of random bases tested by IsPrimeBig, on
+// top of the Baillie-PSW test performed by math/big.
+const millerRabinRounds = 20
+
+// IsPrimeBig reports whether n is prime. It is exact for n < 2^64 and
+// otherwise wrong with a probability below 4^-20.
+func IsPrimeBig(n *big.Int) bool {
+	if n.Sign() <= 0 {
+		return false
+	}
+	if n.IsUint64() {
+		return IsPrime64(n.Uint64())
+	}
+	return n.ProbablyPrime(millerRabinRounds)
+}
@@ -0,0 +1,167 @@
+package prime
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"sort"
+	"testing"
+)
+
+func factorize64(t *testing.T, n uint64) []Factor64 {
+	t.Helper()
+	var fs []Factor64
+	err := Factorize64(context.Background(), n, func(f Factor64) error {
+		fs = append(fs, f)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Factorize64(%d) = %v", n, err)
+	}
+	sort.Slice(fs, func(i, j int) bool { return fs[i].Prime < fs[j].Prime })
+	return fs
+}
+
+func TestFactorize64(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want []Factor64
+	}{
+		{0, nil},
+		{1, nil},
+		{2, []Factor64{{2, 1}}},
+		{1024, []Factor64{{2, 10}}},
+		{997 * 997, []Factor64{{997, 2}}},
+		{1009 * 1009, []Factor64{{1009, 2}}},
+		{math.MaxUint64, []Factor64{{3, 1}, {5, 1}, {17, 1}, {257, 1}, {641, 1}, {65537, 1}, {6700417, 1}}},
+		// the largest 64-bit prime, and the square of the largest 32-bit one
+		{18446744073709551557, []Factor64{{18446744073709551557, 1}}},
+		{4294967291 * 4294967291, []Factor64{{4294967291, 2}}},
+		{4294967291 * 4294967279, []Factor64{{4294967279, 1}, {4294967291, 1}}},
+		{2642245 * 2642245 * 2642245, []Factor64{{5, 3}, {41, 3}, {12889, 3}}},
+		// Carmichael numbers
+		{561, []Factor64{{3, 1}, {11, 1}, {17, 1}}},
+		{41041, []Factor64{{7, 1}, {11, 1}, {13, 1}, {41, 1}}},
+		{321197185, []Factor64{{5, 1}, {19, 1}, {23, 1}, {29, 1}, {37, 1}, {137, 1}}},
+		{8911, []Factor64{{7, 1}, {19, 1}, {67, 1}}},
+		{3215031751, []Factor64{{151, 1}, {751, 1}, {28351, 1}}},
+		// a Carmichael number that is a strong pseudoprime to the bases up
+		// to 23
+		{3825123056546413051, []Factor64{{149491, 1}, {747451, 1}, {34233211, 1}}},
+	}
+	for _, tt := range tests {
+		got := factorize64(t, tt.n)
+		if len(got) != len(tt.want) {
+			t.Errorf("Factorize64(%d) = %v, want %v", tt.n, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Factorize64(%d) = %v, want %v", tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFactorize64Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the factors of this semiprime are too large for trial division
+	err := Factorize64(ctx, 4294967291*4294967279, func(Factor64) error { return nil })
+	if err != context.Canceled {
+		t.Errorf("Factorize64() = %v, want context.Canceled", err)
+	}
+}
+
+func TestFactorizeBig(t *testing.T) {
+	m61 := new(big.Int).SetUint64(1<<61 - 1)
+	m31 := big.NewInt(1<<31 - 1)
+
+	tests := []struct {
+		name string
+		n    *big.Int
+		want map[string]int
+	}{
+		{"mersenne product", new(big.Int).Mul(m61, m31), map[string]int{m61.String(): 1, m31.String(): 1}},
+		{"mersenne square", new(big.Int).Mul(m61, m61), map[string]int{m61.String(): 2}},
+		{"mersenne cube", new(big.Int).Exp(m61, big.NewInt(3), nil), map[string]int{m61.String(): 3}},
+		{"square of a product", new(big.Int).Exp(new(big.Int).Mul(m61, m31), big.NewInt(2), nil), map[string]int{m61.String(): 2, m31.String(): 2}},
+		{"2^64", new(big.Int).Lsh(big.NewInt(1), 64), map[string]int{"2": 64}},
+		{"2^64+1", new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(1)), map[string]int{"274177": 1, "67280421310721": 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := new(big.Int).Set(tt.n)
+			got := map[string]int{}
+			err := FactorizeBig(context.Background(), n, func(f FactorBig) error {
+				got[f.Prime.String()] += f.Exponent
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n.Cmp(tt.n) != 0 {
+				t.Errorf("FactorizeBig modified n to %v", n)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FactorizeBig(%v) = %v, want %v", tt.n, got, tt.want)
+			}
+			for p, e := range tt.want {
+				if got[p] != e {
+					t.Errorf("FactorizeBig(%v) = %v, want %v", tt.n, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIsPrime64(t *testing.T) {
+	primes := []uint64{2, 3, 997, 1009, 4294967291, 18446744073709551557}
+	composites := []uint64{0, 1, 4, 561, 1009 * 1009, 3825123056546413051, 3215031751, math.MaxUint64}
+	for _, n := range primes {
+		if !IsPrime64(n) || !IsPrimeBig(new(big.Int).SetUint64(n)) {
+			t.Errorf("IsPrime64(%d) = false", n)
+		}
+	}
+	for _, n := range composites {
+		if IsPrime64(n) {
+			t.Errorf("IsPrime64(%d) = true", n)
+		}
+	}
+}
+
+func TestSieve(t *testing.T) {
+	ctx := context.Background()
+	count := func(from, to uint64) (n int, last uint64) {
+		err := Sieve(ctx, from, to, func(primes []uint64) error {
+			for _, p := range primes {
+				if p < from || p > to || p <= last {
+					t.Fatalf("Sieve(%d, %d) emitted %d after %d", from, to, p, last)
+				}
+				last = p
+			}
+			n += len(primes)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n, last
+	}
+
+	if n, last := count(0, 1000000); n != 78498 || last != 999983 {
+		t.Errorf("primes up to 10^6: %d up to %d, want 78498 up to 999983", n, last)
+	}
+	if n, _ := count(segmentSize-100, segmentSize+100); n != 19 {
+		t.Errorf("primes around the first segment boundary: %d, want 19", n)
+	}
+	// above MaxSieve, candidates are tested one by one
+	if n, _ := count(MaxSieve, MaxSieve+1000); n != 30 {
+		t.Errorf("primes in [10^14, 10^14+1000]: %d, want 30", n)
+	}
+
+	if p, err := NthPrime(ctx, 10000); err != nil || p != 104729 {
+		t.Errorf("NthPrime(10000) = %d, %v, want 104729", p, err)
+	}
+}
@@ -0,0 +1,172 @@
+package prime
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// segmentSize is the number of integers sieved at a time.
+const segmentSize = 1 << 18
+
+// MaxSieve is the largest bound for which Sieve uses the sieve of
+// Eratosthenes. Above it, the sieving primes would not fit in memory and
+// candidates are tested with Miller–Rabin instead.
+const MaxSieve = 1e14
+
+// isqrt returns the floor of the square root of n.
+func isqrt(n uint64) uint64 {
+	r := uint64(math.Sqrt(float64(n)))
+	for r > 0 && r > n/r {
+		r--
+	}
+	for r+1 <= n/(r+1) {
+		r++
+	}
+	return r
+}
+
+// primesUpTo returns the primes up to n with a plain sieve.
+func primesUpTo(n uint64) []uint64 {
+	if n < 2 {
+		return nil
+	}
+	composite := make([]bool, n+1)
+	var primes []uint64
+	for i := uint64(2); i <= n; i++ {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j <= n; j += i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
+
+// Sieve calls emit with the primes between from and to, both included, in
+// ascending order. The primes are found with a segmented sieve of
+// Eratosthenes and emitted one segment at a time. It stops with the
+// context's error when ctx is done, or with the error returned by emit.
+func Sieve(ctx context.Context, from, to uint64, emit func(primes []uint64) error) error {
+	if from < 2 {
+		from = 2
+	}
+	if to < from {
+		return nil
+	}
+	if to > MaxSieve {
+		return testRange(ctx, from, to, emit)
+	}
+
+	base := primesUpTo(isqrt(to))
+	composite := make([]bool, segmentSize)
+
+	for lo := from; lo <= to; lo += segmentSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hi := lo + segmentSize - 1
+		if hi > to {
+			hi = to
+		}
+
+		segment := composite[:hi-lo+1]
+		for i := range segment {
+			segment[i] = false
+		}
+		for _, p := range base {
+			if p*p > hi {
+				break
+			}
+			start := (lo + p - 1) / p * p
+			if start < p*p {
+				start = p * p
+			}
+			for m := start; m <= hi; m += p {
+				segment[m-lo] = true
+			}
+		}
+
+		var primes []uint64
+		for i, c := range segment {
+			if !c {
+				primes = append(primes, lo+uint64(i))
+			}
+		}
+		if len(primes) > 0 {
+			if err := emit(primes); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// testRange is Sieve for ranges beyond MaxSieve.
+func testRange(ctx context.Context, from, to uint64, emit func(primes []uint64) error) error {
+	for lo := from; ; lo += segmentSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		hi := lo + segmentSize - 1
+		if hi > to || hi < lo {
+			hi = to
+		}
+
+		var primes []uint64
+		for n := lo; ; n++ {
+			if IsPrime64(n) {
+				primes = append(primes, n)
+			}
+			if n == hi {
+				break
+			}
+		}
+		if len(primes) > 0 {
+			if err := emit(primes); err != nil {
+				return err
+			}
+		}
+
+		if hi == to {
+			return nil
+		}
+	}
+}
+
+// errFound stops the sieve of NthPrime once the prime is found.
+var errFound = errors.New("found")
+
+// NthPrime returns the nth prime, counting from NthPrime(1) = 2. It
+// returns 0 if n is 0. It stops with the context's error when ctx is done.
+func NthPrime(ctx context.Context, n uint64) (uint64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+
+	// p(n) < n (ln n + ln ln n) for n >= 6
+	bound := uint64(13)
+	if n >= 6 {
+		ln := math.Log(float64(n))
+		bound = uint64(float64(n) * (ln + math.Log(ln)))
+	}
+
+	var nth uint64
+	count := uint64(0)
+	err := Sieve(ctx, 2, bound, func(primes []uint64) error {
+		if count+uint64(len(primes)) >= n {
+			nth = primes[n-count-1]
+			return errFound
+		}
+		count += uint64(len(primes))
+		return nil
+	})
+	if err != errFound {
+		return 0, err
+	}
+	return nth, nil
+}
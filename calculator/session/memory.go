@@ -0,0 +1,125 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is the idle TTL of sessions when none is configured.
+const DefaultTTL = 30 * time.Minute
+
+// DefaultMaxSessions is the number of sessions a MemoryStore holds when no
+// limit is configured.
+const DefaultMaxSessions = 10000
+
+// MemoryStore is a Store keeping sessions in memory. Sessions are lost when
+// the process exits.
+type MemoryStore struct {
+	ttl         time.Duration
+	maxSessions int
+
+	mu        sync.Mutex
+	sessions  map[string]*memorySession
+	lastSweep time.Time
+}
+
+type memorySession struct {
+	s        *Session
+	lastUsed time.Time
+}
+
+// NewMemoryStore creates an empty store holding at most maxSessions
+// sessions. A non-positive ttl means DefaultTTL and a non-positive
+// maxSessions DefaultMaxSessions.
+func NewMemoryStore(ttl time.Duration, maxSessions int) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxSessions <= 0 {
+		maxSessions = DefaultMaxSessions
+	}
+	return &MemoryStore{ttl: ttl, maxSessions: maxSessions, sessions: make(map[string]*memorySession)}
+}
+
+// TTL implements Store.
+func (m *MemoryStore) TTL() time.Duration {
+	return m.ttl
+}
+
+// sweep drops the expired sessions, at most once a minute unless force is
+// set. m.mu must be held.
+func (m *MemoryStore) sweep(now time.Time, force bool) {
+	if !force && now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	for id, ms := range m.sessions {
+		if now.Sub(ms.lastUsed) > m.ttl {
+			delete(m.sessions, id)
+		}
+	}
+	m.lastSweep = now
+}
+
+// Create implements Store.
+func (m *MemoryStore) Create(ctx context.Context, s *Session) error {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now, false)
+	if len(m.sessions) >= m.maxSessions {
+		m.sweep(now, true)
+		if len(m.sessions) >= m.maxSessions {
+			return ErrTooManySessions
+		}
+	}
+	m.sessions[s.ID] = &memorySession{s: clone(s), lastUsed: now}
+	return nil
+}
+
+// Update implements Store.
+func (m *MemoryStore) Update(ctx context.Context, id string, fn func(*Session) error) error {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now, false)
+	ms, ok := m.sessions[id]
+	if !ok || now.Sub(ms.lastUsed) > m.ttl {
+		delete(m.sessions, id)
+		return ErrNotFound
+	}
+
+	// fn works on a copy so that a failure leaves the session untouched
+	s := clone(ms.s)
+	if err := fn(s); err != nil {
+		return err
+	}
+	ms.s = s
+	ms.lastUsed = now
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+func clone(s *Session) *Session {
+	c := &Session{
+		ID:      s.ID,
+		History: append([]Entry(nil), s.History...),
+		Memory:  make(map[string]float64, len(s.Memory)),
+	}
+	for k, v := range s.Memory {
+		c.Memory[k] = v
+	}
+	return c
+}
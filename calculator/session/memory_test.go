@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreMaxSessions(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore(time.Hour, 2)
+	var ids []string
+	for i := 0; i < 2; i++ {
+		s, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Create(ctx, s); err != nil {
+			t.Fatalf("Create() #%d = %v", i, err)
+		}
+		ids = append(ids, s.ID)
+	}
+
+	s, _ := New()
+	if err := m.Create(ctx, s); !errors.Is(err, ErrTooManySessions) {
+		t.Fatalf("Create() over the limit = %v, want ErrTooManySessions", err)
+	}
+
+	if err := m.Delete(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Create(ctx, s); err != nil {
+		t.Errorf("Create() after a delete = %v", err)
+	}
+}
+
+func TestMemoryStoreFullSweepsExpired(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore(time.Hour, 1)
+	old, _ := New()
+	if err := m.Create(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	m.sessions[old.ID].lastUsed = time.Now().Add(-2 * time.Hour)
+
+	s, _ := New()
+	if err := m.Create(ctx, s); err != nil {
+		t.Errorf("Create() with an expired session in a full store = %v", err)
+	}
+}
+
+func TestSessionStore(t *testing.T) {
+	s, _ := New()
+	for i := 0; i < MaxRegisters; i++ {
+		if err := s.Store(string(rune('a'+i)), float64(i)); err != nil {
+			t.Fatalf("Store() #%d = %v", i, err)
+		}
+	}
+	if err := s.Store("a", 42); err != nil {
+		t.Errorf("overwriting a register = %v", err)
+	}
+	if err := s.Store("zz", 1); !errors.Is(err, ErrMemoryFull) {
+		t.Errorf("Store() of a new register = %v, want ErrMemoryFull", err)
+	}
+	if s.Memory["a"] != 42 || len(s.Memory) != MaxRegisters {
+		t.Errorf("memory = %v", s.Memory)
+	}
+}
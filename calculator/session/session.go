@@ -0,0 +1,170 @@
+// Package session keeps the per-client state of calculator sessions: the
+// history of results and the memory registers.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the request metadata holding the session ID.
+const MetadataKey = "x-session-id"
+
+// RequestMetadataKey is the request metadata identifying a call across its
+// attempts, see grpcclient.RequestIDMetadataKey.
+const RequestMetadataKey = "x-request-id"
+
+// MaxHistory is the number of results kept per session. Older ones are
+// dropped.
+const MaxHistory = 100
+
+// MaxInput is the longest input recorded in the history, in bytes. Longer
+// ones are truncated and end with "…".
+const MaxInput = 256
+
+// MaxRegisters is the number of memory registers a session may hold.
+const MaxRegisters = 26
+
+// MaxRegisterName is the maximum length in bytes of the name of a memory
+// register.
+const MaxRegisterName = 32
+
+var (
+	// ErrNotFound is returned by stores for sessions that do not exist or
+	// have expired.
+	ErrNotFound = errors.New("session not found or expired")
+
+	// ErrTooManySessions is returned by stores that cannot hold another
+	// session.
+	ErrTooManySessions = errors.New("too many sessions")
+
+	// ErrMemoryFull is returned when storing a new register in a session
+	// that already holds MaxRegisters.
+	ErrMemoryFull = errors.New("memory registers full")
+)
+
+// Entry is a result recorded in the history of a session.
+type Entry struct {
+	Method string
+	// Input describes the operation, e.g. "3 + 4".
+	Input  string
+	Result float64
+	Time   time.Time
+	// RequestID identifies the call that recorded the entry, if known.
+	RequestID string
+}
+
+// Session is the state of a session.
+type Session struct {
+	ID      string
+	History []Entry
+	Memory  map[string]float64
+}
+
+// New creates a session with a random ID.
+func New() (*Session, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &Session{ID: hex.EncodeToString(b), Memory: make(map[string]float64)}, nil
+}
+
+// Record appends e to the history, truncating its input to MaxInput
+// bytes. An entry with the RequestID of one already in the history is a
+// repeated attempt of the same call and is dropped.
+func (s *Session) Record(e Entry) {
+	if e.RequestID != "" {
+		for _, h := range s.History {
+			if h.RequestID == e.RequestID {
+				return
+			}
+		}
+	}
+	e.Input = truncate(e.Input, MaxInput)
+	s.History = append(s.History, e)
+	if n := len(s.History) - MaxHistory; n > 0 {
+		s.History = append(s.History[:0], s.History[n:]...)
+	}
+}
+
+// Store sets the memory register name to v. It fails with ErrMemoryFull if
+// name is a new register and the session already holds MaxRegisters.
+func (s *Session) Store(name string, v float64) error {
+	if _, ok := s.Memory[name]; !ok && len(s.Memory) >= MaxRegisters {
+		return ErrMemoryFull
+	}
+	s.Memory[name] = v
+	return nil
+}
+
+// truncate shortens s to at most n bytes, ending with "…", without
+// splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	const ellipsis = "…"
+	i := n - len(ellipsis)
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i] + ellipsis
+}
+
+// Ans returns the last result of the session, if any.
+func (s *Session) Ans() (float64, bool) {
+	if len(s.History) == 0 {
+		return 0, false
+	}
+	return s.History[len(s.History)-1].Result, true
+}
+
+// Store keeps sessions until they have been idle for longer than a TTL.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Create stores a new session. It returns ErrTooManySessions if the
+	// store is full.
+	Create(ctx context.Context, s *Session) error
+	// Update calls fn with the session and stores the changes it makes,
+	// atomically. Every update resets the idle TTL. It returns ErrNotFound
+	// if the session does not exist or has expired, and the error of fn if
+	// it fails, in which case the changes are discarded.
+	Update(ctx context.Context, id string, fn func(*Session) error) error
+	// Delete removes a session. Deleting a missing session is not an error.
+	Delete(ctx context.Context, id string) error
+	// TTL returns how long sessions are kept once idle.
+	TTL() time.Duration
+}
+
+// FromContext returns the session ID of an incoming call, or "" if it has
+// none.
+func FromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(MetadataKey); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// RequestIDFromContext returns the request ID of an incoming call, or "" if
+// it has none.
+func RequestIDFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(RequestMetadataKey); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// NewOutgoingContext returns a context that makes outgoing calls part of
+// the session.
+func NewOutgoingContext(ctx context.Context, id string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, id)
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRecordTruncatesInput(t *testing.T) {
+	tests := []struct {
+		name, input string
+	}{
+		{"short", "3 + 4"},
+		{"exact", strings.Repeat("1", MaxInput)},
+		{"oversized", strings.Repeat("1+", 2<<20)},
+		{"multibyte", strings.Repeat("é", MaxInput)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := New()
+			s.Record(Entry{Method: "Evaluate", Input: tt.input})
+			got := s.History[0].Input
+			if len(tt.input) <= MaxInput {
+				if got != tt.input {
+					t.Errorf("Input = %q, want it unchanged", got)
+				}
+				return
+			}
+			if len(got) > MaxInput || !strings.HasSuffix(got, "…") || !utf8.ValidString(got) {
+				t.Errorf("Input of %d bytes recorded as %d bytes %q", len(tt.input), len(got), got)
+			}
+			if !strings.HasPrefix(tt.input, strings.TrimSuffix(got, "…")) {
+				t.Errorf("Input %q is not a prefix of the original", got)
+			}
+		})
+	}
+}
+
+func TestRecordKeepsMaxHistory(t *testing.T) {
+	s, _ := New()
+	for i := 0; i < MaxHistory+10; i++ {
+		s.Record(Entry{Result: float64(i)})
+	}
+	if len(s.History) != MaxHistory || s.History[0].Result != 10 {
+		t.Errorf("%d entries from %v, want %d from 10", len(s.History), s.History[0].Result, MaxHistory)
+	}
+	if ans, _ := s.Ans(); ans != MaxHistory+9 {
+		t.Errorf("Ans() = %v", ans)
+	}
+}
+
+func TestRecordDropsRepeatedRequests(t *testing.T) {
+	s, _ := New()
+	s.Record(Entry{Input: "1 + 2", Result: 3, RequestID: "a"})
+	s.Record(Entry{Input: "1 + 2", Result: 3, RequestID: "a"})
+	s.Record(Entry{Input: "1 + 2", Result: 3, RequestID: "b"})
+	s.Record(Entry{Input: "1 + 2", Result: 3})
+	s.Record(Entry{Input: "1 + 2", Result: 3})
+	if len(s.History) != 4 {
+		t.Errorf("recorded %d entries, want 4: %+v", len(s.History), s.History)
+	}
+}
@@ -0,0 +1,91 @@
+// Package stats implements online algorithms computing summary statistics
+// over streams of numbers in constant or bounded memory.
+package stats
+
+import "math"
+
+// Summary accumulates the count, sum, mean, variance and extremes of a
+// stream of numbers. The mean and variance are updated with Welford's
+// algorithm and the sum with Neumaier's compensated summation, so they
+// stay accurate over long streams. The zero value is an empty summary.
+type Summary struct {
+	count    int64
+	sum      float64
+	sumComp  float64
+	mean     float64
+	m2       float64
+	min, max float64
+}
+
+// Add adds x to the summary.
+func (s *Summary) Add(x float64) {
+	if s.count == 0 {
+		s.min, s.max = x, x
+	}
+	s.min = math.Min(s.min, x)
+	s.max = math.Max(s.max, x)
+
+	t := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.sumComp += (s.sum - t) + x
+	} else {
+		s.sumComp += (x - t) + s.sum
+	}
+	s.sum = t
+
+	s.count++
+	delta := x - s.mean
+	s.mean += delta / float64(s.count)
+	s.m2 += delta * (x - s.mean)
+}
+
+// Count returns the number of values added.
+func (s *Summary) Count() int64 {
+	return s.count
+}
+
+// Sum returns the sum of the values.
+func (s *Summary) Sum() float64 {
+	return s.sum + s.sumComp
+}
+
+// Mean returns the arithmetic mean of the values, or NaN if there are none.
+func (s *Summary) Mean() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.mean
+}
+
+// Variance returns the sample variance of the values, 0 for a single value
+// and NaN if there are none.
+func (s *Summary) Variance() float64 {
+	switch s.count {
+	case 0:
+		return math.NaN()
+	case 1:
+		return 0
+	}
+	return s.m2 / float64(s.count-1)
+}
+
+// StdDev returns the sample standard deviation of the values.
+func (s *Summary) StdDev() float64 {
+	return math.Sqrt(s.Variance())
+}
+
+// Min returns the smallest value, or NaN if there are none.
+func (s *Summary) Min() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.min
+}
+
+// Max returns the largest value, or NaN if there are none.
+func (s *Summary) Max() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	return s.max
+}
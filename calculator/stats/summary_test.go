@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSummaryEmpty(t *testing.T) {
+	var s Summary
+	for name, v := range map[string]float64{"mean": s.Mean(), "variance": s.Variance(), "min": s.Min(), "max": s.Max()} {
+		if !math.IsNaN(v) {
+			t.Errorf("%s of no values = %v, want NaN", name, v)
+		}
+	}
+	if s.Count() != 0 || s.Sum() != 0 {
+		t.Errorf("count %d and sum %v of no values", s.Count(), s.Sum())
+	}
+
+	s.Add(-3)
+	if s.Variance() != 0 || s.Min() != -3 || s.Max() != -3 || s.Mean() != -3 {
+		t.Errorf("summary of -3: mean %v, variance %v, min %v, max %v", s.Mean(), s.Variance(), s.Min(), s.Max())
+	}
+}
+
+func TestSummaryAccuracy(t *testing.T) {
+	tests := []struct {
+		name     string
+		xs       []float64
+		repeat   int
+		sum      float64
+		mean     float64
+		variance float64
+	}{
+		// the naive sum of squares loses every digit of these
+		{"large offset", []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}, 1, 4e9 + 40, 1e9 + 10, 30},
+		{"larger offset", []float64{1e15 + 4, 1e15 + 7, 1e15 + 13, 1e15 + 16}, 1, 4e15 + 40, 1e15 + 10, 30},
+		{"cancellation", []float64{1e100, 1, -1e100}, 1, 1, 1.0 / 3, 1e200},
+		// 0.1 is not exact in binary: the naive sum of a million drifts
+		{"long stream", []float64{0.1}, 1000000, 100000, 0.1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Summary
+			for i := 0; i < tt.repeat; i++ {
+				for _, x := range tt.xs {
+					s.Add(x)
+				}
+			}
+			check := func(what string, got, want, tolerance float64) {
+				if math.Abs(got-want) > tolerance*math.Max(1, math.Abs(want)) {
+					t.Errorf("%s = %v, want %v", what, got, want)
+				}
+			}
+			check("sum", s.Sum(), tt.sum, 1e-15)
+			check("variance", s.Variance(), tt.variance, 1e-12)
+			if tt.name != "cancellation" {
+				check("mean", s.Mean(), tt.mean, 1e-15)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultCompression is the compression of a t-digest created with a
+// non-positive compression. Larger values are more accurate and use more
+// memory.
+const DefaultCompression = 100
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest estimates quantiles of a stream of numbers with a merging
+// t-digest (Dunning, 2019). It keeps O(compression) centroids, is exact
+// while few values were added and most accurate near the extreme
+// quantiles.
+type TDigest struct {
+	compression float64
+	centroids   []centroid // sorted by mean
+	buffer      []centroid // unmerged values
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest creates an empty t-digest.
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = DefaultCompression
+	}
+	return &TDigest{
+		compression: compression,
+		buffer:      make([]centroid, 0, 5*int(math.Ceil(compression))),
+	}
+}
+
+// Add adds x to the digest.
+func (t *TDigest) Add(x float64) {
+	if t.count == 0 {
+		t.min, t.max = x, x
+	}
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	t.count++
+
+	t.buffer = append(t.buffer, centroid{mean: x, weight: 1})
+	if len(t.buffer) == cap(t.buffer) {
+		t.merge()
+	}
+}
+
+// scale is the k1 scale function, which bounds the size of centroids near
+// the tails more tightly than in the middle.
+func (t *TDigest) scale(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+// scaleInverse is the inverse of scale. Beyond the end of the scale it
+// returns 1 rather than folding back, so that the last values merge.
+func (t *TDigest) scaleInverse(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
+
+// merge folds the buffered values into the centroids.
+func (t *TDigest) merge() {
+	if len(t.buffer) == 0 {
+		return
+	}
+
+	all := append(t.centroids, t.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+	t.buffer = t.buffer[:0]
+
+	merged := make([]centroid, 0, len(all))
+	merged = append(merged, all[0])
+	seen := 0.0 // weight of the centroids before the current one
+	limit := t.scaleInverse(t.scale(0) + 1)
+
+	for _, c := range all[1:] {
+		cur := &merged[len(merged)-1]
+		if q := (seen + cur.weight + c.weight) / t.count; q <= limit {
+			cur.weight += c.weight
+			cur.mean += (c.mean - cur.mean) * c.weight / cur.weight
+			continue
+		}
+		seen += cur.weight
+		limit = t.scaleInverse(t.scale(seen/t.count) + 1)
+		merged = append(merged, c)
+	}
+
+	t.centroids = merged
+}
+
+// Count returns the number of values added.
+func (t *TDigest) Count() int64 {
+	return int64(t.count)
+}
+
+// Quantile returns an estimate of the q-quantile of the values, 0 <= q <= 1,
+// interpolating between centroids. It returns NaN if there are no values.
+func (t *TDigest) Quantile(q float64) float64 {
+	t.merge()
+
+	switch {
+	case len(t.centroids) == 0:
+		return math.NaN()
+	case len(t.centroids) == 1 || q <= 0:
+		if q >= 1 {
+			return t.max
+		}
+		if q <= 0 {
+			return t.min
+		}
+		return t.centroids[0].mean
+	case q >= 1:
+		return t.max
+	}
+
+	// position of the quantile, with each centroid centered on the middle
+	// of its weight
+	index := q * t.count
+
+	first := t.centroids[0]
+	if index < first.weight/2 {
+		return t.min + (first.mean-t.min)*index/(first.weight/2)
+	}
+
+	seen := 0.0
+	for i := 0; i < len(t.centroids)-1; i++ {
+		c, next := t.centroids[i], t.centroids[i+1]
+		left := seen + c.weight/2
+		right := seen + c.weight + next.weight/2
+		if index < right {
+			return c.mean + (next.mean-c.mean)*(index-left)/(right-left)
+		}
+		seen += c.weight
+	}
+
+	last := t.centroids[len(t.centroids)-1]
+	left := t.count - last.weight/2
+	if index <= left || last.weight == 0 {
+		return last.mean
+	}
+	return last.mean + (t.max-last.mean)*(index-left)/(last.weight/2)
+}
@@ -0,0 +1,97 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestTDigestEmpty(t *testing.T) {
+	d := NewTDigest(0)
+	if q := d.Quantile(0.5); !math.IsNaN(q) || d.Count() != 0 {
+		t.Errorf("median of no values = %v over %d, want NaN", q, d.Count())
+	}
+}
+
+func TestTDigestFewValuesAreExact(t *testing.T) {
+	d := NewTDigest(100)
+	for _, x := range []float64{5, 1, 3} {
+		d.Add(x)
+	}
+	for _, tt := range []struct{ q, want float64 }{{0, 1}, {0.5, 3}, {1, 5}} {
+		if got := d.Quantile(tt.q); got != tt.want {
+			t.Errorf("Quantile(%v) of 1, 3, 5 = %v, want %v", tt.q, got, tt.want)
+		}
+	}
+
+	single := NewTDigest(100)
+	single.Add(7)
+	for _, q := range []float64{0, 0.3, 1} {
+		if got := single.Quantile(q); got != 7 {
+			t.Errorf("Quantile(%v) of 7 = %v", q, got)
+		}
+	}
+}
+
+// rankError returns how far q is from the ranks, as fractions, of v in
+// sorted. Duplicates of v span a range of ranks.
+func rankError(sorted []float64, q, v float64) float64 {
+	n := float64(len(sorted))
+	lo := float64(sort.SearchFloat64s(sorted, v)) / n
+	hi := float64(sort.Search(len(sorted), func(i int) bool { return sorted[i] > v })) / n
+	return math.Max(0, math.Max(lo-q, q-hi))
+}
+
+func TestTDigestAccuracy(t *testing.T) {
+	const n = 200000
+	rnd := rand.New(rand.NewSource(1))
+	distributions := map[string]func() float64{
+		"uniform":     rnd.Float64,
+		"normal":      rnd.NormFloat64,
+		"exponential": rnd.ExpFloat64,
+		// many duplicates
+		"discrete": func() float64 { return float64(rnd.Intn(10)) },
+	}
+
+	for name, next := range distributions {
+		d := NewTDigest(100)
+		xs := make([]float64, n)
+		for i := range xs {
+			xs[i] = next()
+			d.Add(xs[i])
+		}
+		sort.Float64s(xs)
+
+		if d.Count() != n {
+			t.Errorf("%s: Count() = %d, want %d", name, d.Count(), n)
+		}
+		if d.Quantile(0) != xs[0] || d.Quantile(1) != xs[n-1] {
+			t.Errorf("%s: extremes %v and %v, want %v and %v", name, d.Quantile(0), d.Quantile(1), xs[0], xs[n-1])
+		}
+
+		// the error is smallest near the tails
+		for _, tt := range []struct{ q, maxErr float64 }{
+			{0.001, 0.0005}, {0.01, 0.002}, {0.1, 0.01}, {0.5, 0.01},
+			{0.9, 0.01}, {0.99, 0.002}, {0.999, 0.0005},
+		} {
+			v := d.Quantile(tt.q)
+			if e := rankError(xs, tt.q, v); e > tt.maxErr {
+				t.Errorf("%s: Quantile(%v) = %v has a rank error of %.5f, over %v", name, tt.q, v, e, tt.maxErr)
+			}
+		}
+	}
+}
+
+func TestTDigestBoundedSize(t *testing.T) {
+	d := NewTDigest(50)
+	for i := 0; i < 1000000; i++ {
+		d.Add(float64(i))
+	}
+	d.merge()
+	// the k1 scale function spans compression/2 units, each of which
+	// holds at most two centroids once merged
+	if n := len(d.centroids); n > 50 {
+		t.Errorf("%d centroids for a compression of 50", n)
+	}
+}
@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"math"
+	"time"
+)
+
+// Aggregate is a function computed over the values of a Window.
+type Aggregate int
+
+const (
+	Min Aggregate = iota + 1
+	Max
+	Sum
+	Mean
+	// EWMA is the exponentially weighted moving average, starting at the
+	// oldest value of the window. Sliding windows update it as values
+	// enter and leave the window, in constant time.
+	EWMA
+)
+
+// DefaultAlpha is the EWMA smoothing factor used when none is set.
+const DefaultAlpha = 0.5
+
+// WindowOptions configures a Window. At most one of Size and Duration may
+// be set; when neither is, the window spans the whole stream.
+type WindowOptions struct {
+	Aggregate Aggregate
+	// Alpha is the weight of the newest value in EWMA, in (0, 1].
+	Alpha float64
+	// Size is the number of values in the window.
+	Size int
+	// Duration is the time span of the window.
+	Duration time.Duration
+	// Tumbling windows are consecutive and do not overlap: the aggregate
+	// restarts from scratch at the beginning of each. Sliding windows move
+	// with every value.
+	Tumbling bool
+}
+
+type sample struct {
+	x   float64
+	t   time.Time
+	seq int64
+}
+
+// compensatedSum is a sum with Neumaier's compensation of the rounding
+// errors, so that adding and then removing large values does not wipe out
+// the small ones.
+type compensatedSum struct {
+	sum, c float64
+}
+
+func (s *compensatedSum) add(x float64) {
+	t := s.sum + x
+	if math.Abs(s.sum) >= math.Abs(x) {
+		s.c += (s.sum - t) + x
+	} else {
+		s.c += (x - t) + s.sum
+	}
+	s.sum = t
+}
+
+func (s *compensatedSum) value() float64 {
+	return s.sum + s.c
+}
+
+// Window computes an aggregate over a count- or time-based window of a
+// stream of numbers. Tumbling and whole-stream windows use constant memory,
+// sliding windows memory proportional to their size. Every operation takes
+// amortized constant time.
+type Window struct {
+	opts WindowOptions
+
+	// sliding windows only
+	samples    []sample // oldest first
+	mins, maxs []sample // monotonic candidates for the min and max
+	evicted    int      // since the sum and EWMA were last recomputed
+
+	n     int
+	seq   int64
+	sum   compensatedSum
+	min   float64
+	max   float64
+	ewma  float64
+	start time.Time // of the current time-based tumbling window
+}
+
+// NewWindow creates an empty window.
+func NewWindow(opts WindowOptions) *Window {
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = DefaultAlpha
+	}
+	return &Window{opts: opts}
+}
+
+func (w *Window) sliding() bool {
+	return !w.opts.Tumbling && (w.opts.Size > 0 || w.opts.Duration > 0)
+}
+
+// Add adds x, received at t, to the window and returns the aggregate of the
+// values in the window and their number.
+func (w *Window) Add(x float64, t time.Time) (float64, int) {
+	if w.sliding() {
+		w.slide(x, t)
+	} else {
+		w.tumble(x, t)
+	}
+	return w.value(), w.n
+}
+
+// tumble adds x to a tumbling or whole-stream window.
+func (w *Window) tumble(x float64, t time.Time) {
+	switch {
+	case w.opts.Size > 0 && w.n == w.opts.Size:
+		w.n = 0
+	case w.opts.Duration > 0:
+		if w.start.IsZero() {
+			w.start = t
+		}
+		if elapsed := t.Sub(w.start); elapsed >= w.opts.Duration {
+			w.start = w.start.Add(elapsed - elapsed%w.opts.Duration)
+			w.n = 0
+		}
+	}
+
+	if w.n == 0 {
+		w.sum, w.min, w.max, w.ewma = compensatedSum{}, x, x, x
+	}
+	w.n++
+	w.sum.add(x)
+	w.min = math.Min(w.min, x)
+	w.max = math.Max(w.max, x)
+	w.ewma += w.opts.Alpha * (x - w.ewma)
+}
+
+// slide adds x to a sliding window and evicts the values that left it.
+func (w *Window) slide(x float64, t time.Time) {
+	s := sample{x: x, t: t, seq: w.seq}
+	w.seq++
+
+	if len(w.samples) == 0 {
+		w.ewma = x
+	} else {
+		w.ewma += w.opts.Alpha * (x - w.ewma)
+	}
+	w.samples = append(w.samples, s)
+	w.sum.add(x)
+	for len(w.mins) > 0 && w.mins[len(w.mins)-1].x >= x {
+		w.mins = w.mins[:len(w.mins)-1]
+	}
+	w.mins = append(w.mins, s)
+	for len(w.maxs) > 0 && w.maxs[len(w.maxs)-1].x <= x {
+		w.maxs = w.maxs[:len(w.maxs)-1]
+	}
+	w.maxs = append(w.maxs, s)
+
+	for {
+		oldest := w.samples[0]
+		expired := w.opts.Size > 0 && len(w.samples) > w.opts.Size ||
+			w.opts.Duration > 0 && t.Sub(oldest.t) >= w.opts.Duration
+		if !expired {
+			break
+		}
+		w.evict()
+	}
+
+	// The incremental updates accumulate rounding errors. Recomputing
+	// after as many evictions as the window holds bounds them at an
+	// amortized constant cost.
+	if w.evicted >= len(w.samples) {
+		w.recompute()
+	}
+
+	w.n = len(w.samples)
+	w.min, w.max = w.mins[0].x, w.maxs[0].x
+}
+
+// evict removes the oldest sample of a sliding window, which always holds
+// a newer one.
+func (w *Window) evict() {
+	oldest, next := w.samples[0], w.samples[1]
+
+	// The oldest value weighs (1-alpha)^(n-1) in the EWMA and the next one
+	// alpha (1-alpha)^(n-2). Once the next one starts the EWMA, it weighs
+	// (1-alpha)^(n-2), which is (1-alpha)^(n-1) more.
+	w.ewma += math.Pow(1-w.opts.Alpha, float64(len(w.samples)-1)) * (next.x - oldest.x)
+
+	w.samples = w.samples[1:]
+	w.sum.add(-oldest.x)
+	w.evicted++
+	if w.mins[0].seq == oldest.seq {
+		w.mins = w.mins[1:]
+	}
+	if w.maxs[0].seq == oldest.seq {
+		w.maxs = w.maxs[1:]
+	}
+}
+
+// recompute computes the sum and EWMA of a sliding window from scratch.
+func (w *Window) recompute() {
+	w.sum = compensatedSum{}
+	w.ewma = w.samples[0].x
+	for i, s := range w.samples {
+		w.sum.add(s.x)
+		if i > 0 {
+			w.ewma += w.opts.Alpha * (s.x - w.ewma)
+		}
+	}
+	w.evicted = 0
+}
+
+func (w *Window) value() float64 {
+	switch w.opts.Aggregate {
+	case Min:
+		return w.min
+	case Max:
+		return w.max
+	case Sum:
+		return w.sum.value()
+	case Mean:
+		return w.sum.value() / float64(w.n)
+	case EWMA:
+		return w.ewma
+	}
+	return math.NaN()
+}
@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var epoch = time.Unix(0, 0)
+
+func TestSlidingSumCancellation(t *testing.T) {
+	for _, tt := range []struct {
+		agg  Aggregate
+		want float64
+	}{
+		{Sum, 2},
+		{Mean, 1},
+	} {
+		w := NewWindow(WindowOptions{Aggregate: tt.agg, Size: 2})
+		var got float64
+		for i, x := range []float64{1e20, 1, 1} {
+			got, _ = w.Add(x, epoch.Add(time.Duration(i)*time.Second))
+		}
+		if got != tt.want {
+			t.Errorf("aggregate %v of the last 2 of 1e20, 1, 1 = %v, want %v", tt.agg, got, tt.want)
+		}
+	}
+}
+
+// naive computes the aggregate of xs from scratch.
+func naive(agg Aggregate, alpha float64, xs []float64) float64 {
+	switch agg {
+	case Min, Max:
+		v := xs[0]
+		for _, x := range xs {
+			if agg == Min {
+				v = math.Min(v, x)
+			} else {
+				v = math.Max(v, x)
+			}
+		}
+		return v
+	case Sum, Mean:
+		var s float64
+		for _, x := range xs {
+			s += x
+		}
+		if agg == Mean {
+			return s / float64(len(xs))
+		}
+		return s
+	case EWMA:
+		e := xs[0]
+		for _, x := range xs[1:] {
+			e += alpha * (x - e)
+		}
+		return e
+	}
+	return math.NaN()
+}
+
+func TestWindowAgainstNaive(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	xs := make([]float64, 500)
+	for i := range xs {
+		xs[i] = rnd.NormFloat64() * 100
+	}
+
+	tests := []struct {
+		name string
+		opts WindowOptions
+		// window returns the values in the window after adding xs[i]
+		window func(i int) []float64
+	}{
+		{"whole stream", WindowOptions{}, func(i int) []float64 { return xs[:i+1] }},
+		{"sliding count", WindowOptions{Size: 7}, func(i int) []float64 {
+			return xs[maxInt(0, i-6) : i+1]
+		}},
+		{"tumbling count", WindowOptions{Size: 7, Tumbling: true}, func(i int) []float64 {
+			return xs[i-i%7 : i+1]
+		}},
+		// values are added one second apart
+		{"sliding duration", WindowOptions{Duration: 5 * time.Second}, func(i int) []float64 {
+			return xs[maxInt(0, i-4) : i+1]
+		}},
+		{"tumbling duration", WindowOptions{Duration: 5 * time.Second, Tumbling: true}, func(i int) []float64 {
+			return xs[i-i%5 : i+1]
+		}},
+	}
+	for _, tt := range tests {
+		for _, agg := range []Aggregate{Min, Max, Sum, Mean, EWMA} {
+			opts := tt.opts
+			opts.Aggregate = agg
+			opts.Alpha = 0.3
+			w := NewWindow(opts)
+			for i, x := range xs {
+				got, n := w.Add(x, epoch.Add(time.Duration(i)*time.Second))
+				in := tt.window(i)
+				want := naive(agg, opts.Alpha, in)
+				if n != len(in) || math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+					t.Fatalf("%s, aggregate %v, after %d values: got %v over %d, want %v over %d", tt.name, agg, i+1, got, n, want, len(in))
+				}
+			}
+		}
+	}
+}
+
+func TestTumblingDurationSkipsEmptyWindows(t *testing.T) {
+	w := NewWindow(WindowOptions{Aggregate: Sum, Duration: time.Second, Tumbling: true})
+	w.Add(1, epoch)
+	w.Add(2, epoch.Add(500*time.Millisecond))
+	if got, n := w.Add(4, epoch.Add(3500*time.Millisecond)); got != 4 || n != 1 {
+		t.Errorf("after a gap: %v over %d, want 4 over 1", got, n)
+	}
+	if got, n := w.Add(8, epoch.Add(3999*time.Millisecond)); got != 12 || n != 2 {
+		t.Errorf("same window: %v over %d, want 12 over 2", got, n)
+	}
+}
+
+func TestSlidingEWMAIsLinear(t *testing.T) {
+	const size, adds = 100000, 300000
+	w := NewWindow(WindowOptions{Aggregate: EWMA, Size: size})
+
+	start := time.Now()
+	for i := 0; i < adds; i++ {
+		w.Add(float64(i%10), epoch)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("%d adds to a window of %d took %v", adds, size, elapsed)
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,81 @@
+package units
+
+import "math/big"
+
+// Builtin is the registry of the built-in units. Base units are the metre,
+// kilogram, kelvin, second, byte and metre per second.
+var Builtin = newBuiltin()
+
+func newBuiltin() *Registry {
+	r := NewRegistry()
+	for _, u := range builtinUnits {
+		if err := r.Register(u); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// rat parses the exact decimal or fraction s.
+func rat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("units: invalid number " + s)
+	}
+	return r
+}
+
+var builtinUnits = []Unit{
+	{Symbol: "nm", Names: []string{"nanometer", "nanometers", "nanometre", "nanometres"}, Dimension: Length, Scale: rat("1e-9")},
+	{Symbol: "um", Names: []string{"µm", "micrometer", "micrometers", "micrometre", "micrometres", "micron", "microns"}, Dimension: Length, Scale: rat("1e-6")},
+	{Symbol: "mm", Names: []string{"millimeter", "millimeters", "millimetre", "millimetres"}, Dimension: Length, Scale: rat("1e-3")},
+	{Symbol: "cm", Names: []string{"centimeter", "centimeters", "centimetre", "centimetres"}, Dimension: Length, Scale: rat("1e-2")},
+	{Symbol: "m", Names: []string{"meter", "meters", "metre", "metres"}, Dimension: Length, Scale: rat("1")},
+	{Symbol: "km", Names: []string{"kilometer", "kilometers", "kilometre", "kilometres"}, Dimension: Length, Scale: rat("1e3")},
+	{Symbol: "in", Names: []string{"inch", "inches"}, Dimension: Length, Scale: rat("0.0254")},
+	{Symbol: "ft", Names: []string{"foot", "feet"}, Dimension: Length, Scale: rat("0.3048")},
+	{Symbol: "yd", Names: []string{"yard", "yards"}, Dimension: Length, Scale: rat("0.9144")},
+	{Symbol: "mi", Names: []string{"mile", "miles"}, Dimension: Length, Scale: rat("1609.344")},
+	{Symbol: "nmi", Names: []string{"nautical mile", "nautical miles"}, Dimension: Length, Scale: rat("1852")},
+
+	{Symbol: "mg", Names: []string{"milligram", "milligrams"}, Dimension: Mass, Scale: rat("1e-6")},
+	{Symbol: "g", Names: []string{"gram", "grams"}, Dimension: Mass, Scale: rat("1e-3")},
+	{Symbol: "kg", Names: []string{"kilogram", "kilograms"}, Dimension: Mass, Scale: rat("1")},
+	{Symbol: "t", Names: []string{"tonne", "tonnes", "metric ton", "metric tons"}, Dimension: Mass, Scale: rat("1e3")},
+	{Symbol: "oz", Names: []string{"ounce", "ounces"}, Dimension: Mass, Scale: rat("0.028349523125")},
+	{Symbol: "lb", Names: []string{"lbs", "pound", "pounds"}, Dimension: Mass, Scale: rat("0.45359237")},
+	{Symbol: "st", Names: []string{"stone", "stones"}, Dimension: Mass, Scale: rat("6.35029318")},
+
+	{Symbol: "K", Names: []string{"kelvin", "kelvins"}, Dimension: Temperature, Scale: rat("1")},
+	{Symbol: "C", Names: []string{"°C", "celsius"}, Dimension: Temperature, Scale: rat("1"), Offset: rat("273.15")},
+	{Symbol: "F", Names: []string{"°F", "fahrenheit"}, Dimension: Temperature, Scale: rat("5/9"), Offset: rat("45967/180")},
+
+	{Symbol: "ns", Names: []string{"nanosecond", "nanoseconds"}, Dimension: Time, Scale: rat("1e-9")},
+	{Symbol: "us", Names: []string{"µs", "microsecond", "microseconds"}, Dimension: Time, Scale: rat("1e-6")},
+	{Symbol: "ms", Names: []string{"millisecond", "milliseconds"}, Dimension: Time, Scale: rat("1e-3")},
+	{Symbol: "s", Names: []string{"sec", "second", "seconds"}, Dimension: Time, Scale: rat("1")},
+	{Symbol: "min", Names: []string{"minute", "minutes"}, Dimension: Time, Scale: rat("60")},
+	{Symbol: "h", Names: []string{"hr", "hour", "hours"}, Dimension: Time, Scale: rat("3600")},
+	{Symbol: "d", Names: []string{"day", "days"}, Dimension: Time, Scale: rat("86400")},
+	{Symbol: "wk", Names: []string{"week", "weeks"}, Dimension: Time, Scale: rat("604800")},
+
+	{Symbol: "bit", Names: []string{"bits"}, Dimension: DataSize, Scale: rat("1/8")},
+	{Symbol: "B", Names: []string{"byte", "bytes"}, Dimension: DataSize, Scale: rat("1")},
+	{Symbol: "kbit", Names: []string{"Kbit", "kilobit", "kilobits"}, Dimension: DataSize, Scale: rat("125")},
+	{Symbol: "Mbit", Names: []string{"megabit", "megabits"}, Dimension: DataSize, Scale: rat("125000")},
+	{Symbol: "Gbit", Names: []string{"gigabit", "gigabits"}, Dimension: DataSize, Scale: rat("125000000")},
+	{Symbol: "kB", Names: []string{"KB", "kilobyte", "kilobytes"}, Dimension: DataSize, Scale: rat("1e3")},
+	{Symbol: "MB", Names: []string{"megabyte", "megabytes"}, Dimension: DataSize, Scale: rat("1e6")},
+	{Symbol: "GB", Names: []string{"gigabyte", "gigabytes"}, Dimension: DataSize, Scale: rat("1e9")},
+	{Symbol: "TB", Names: []string{"terabyte", "terabytes"}, Dimension: DataSize, Scale: rat("1e12")},
+	{Symbol: "KiB", Names: []string{"kibibyte", "kibibytes"}, Dimension: DataSize, Scale: rat("1024")},
+	{Symbol: "MiB", Names: []string{"mebibyte", "mebibytes"}, Dimension: DataSize, Scale: rat("1048576")},
+	{Symbol: "GiB", Names: []string{"gibibyte", "gibibytes"}, Dimension: DataSize, Scale: rat("1073741824")},
+	{Symbol: "TiB", Names: []string{"tebibyte", "tebibytes"}, Dimension: DataSize, Scale: rat("1099511627776")},
+
+	{Symbol: "m/s", Names: []string{"meters per second", "metres per second"}, Dimension: Speed, Scale: rat("1")},
+	{Symbol: "km/h", Names: []string{"kph", "kmh", "kilometers per hour", "kilometres per hour"}, Dimension: Speed, Scale: rat("5/18")},
+	{Symbol: "mph", Names: []string{"miles per hour"}, Dimension: Speed, Scale: rat("0.44704")},
+	{Symbol: "ft/s", Names: []string{"fps", "feet per second"}, Dimension: Speed, Scale: rat("0.3048")},
+	{Symbol: "kn", Names: []string{"kt", "knot", "knots"}, Dimension: Speed, Scale: rat("463/900")},
+}
@@ -0,0 +1,181 @@
+// Package units converts values between units of measurement.
+package units
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dimension is the physical quantity measured by a unit.
+type Dimension string
+
+const (
+	Length      Dimension = "length"
+	Mass        Dimension = "mass"
+	Temperature Dimension = "temperature"
+	Time        Dimension = "time"
+	DataSize    Dimension = "data size"
+	Speed       Dimension = "speed"
+)
+
+// Unit converts values to and from the base unit of its dimension with
+// base = value*Scale + Offset. Scale and Offset are exact so that, e.g.,
+// 100 °C converts to exactly 212 °F.
+type Unit struct {
+	// Symbol identifies the unit, case-sensitively, e.g. "km" or "MiB".
+	Symbol string
+	// Names are case-insensitive alternatives to the symbol, e.g.
+	// "kilometer" or "kilometers".
+	Names     []string
+	Dimension Dimension
+	Scale     *big.Rat
+	// Offset may be nil for zero.
+	Offset *big.Rat
+}
+
+func (u Unit) toBase(v *big.Rat) *big.Rat {
+	b := new(big.Rat).Mul(v, u.Scale)
+	if u.Offset != nil {
+		b.Add(b, u.Offset)
+	}
+	return b
+}
+
+func (u Unit) fromBase(b *big.Rat) *big.Rat {
+	v := new(big.Rat).Set(b)
+	if u.Offset != nil {
+		v.Sub(v, u.Offset)
+	}
+	return v.Quo(v, u.Scale)
+}
+
+// Registry is a set of units that may be extended at run time. It is safe
+// for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	symbols map[string]Unit
+	names   map[string]Unit
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		symbols: make(map[string]Unit),
+		names:   make(map[string]Unit),
+	}
+}
+
+// Register adds u to the registry. It fails if its symbol or one of its
+// names is already taken, or its scale is zero.
+func (r *Registry) Register(u Unit) error {
+	if u.Symbol == "" || u.Dimension == "" || u.Scale == nil || u.Scale.Sign() == 0 {
+		return fmt.Errorf("units: unit %q needs a symbol, a dimension and a non-zero scale", u.Symbol)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.symbols[u.Symbol]; ok {
+		return fmt.Errorf("units: symbol %q is already registered", u.Symbol)
+	}
+	for _, name := range u.Names {
+		if _, ok := r.names[strings.ToLower(name)]; ok {
+			return fmt.Errorf("units: name %q is already registered", name)
+		}
+	}
+
+	r.symbols[u.Symbol] = u
+	for _, name := range u.Names {
+		r.names[strings.ToLower(name)] = u
+	}
+	return nil
+}
+
+// Lookup finds a unit by symbol or, failing that, by name.
+func (r *Registry) Lookup(s string) (Unit, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if u, ok := r.symbols[s]; ok {
+		return u, true
+	}
+	u, ok := r.names[strings.ToLower(s)]
+	return u, ok
+}
+
+// Units returns the registered units sorted by dimension and scale.
+func (r *Registry) Units() []Unit {
+	r.mu.RLock()
+	units := make([]Unit, 0, len(r.symbols))
+	for _, u := range r.symbols {
+		units = append(units, u)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].Dimension != units[j].Dimension {
+			return units[i].Dimension < units[j].Dimension
+		}
+		if c := units[i].Scale.Cmp(units[j].Scale); c != 0 {
+			return c < 0
+		}
+		return units[i].Symbol < units[j].Symbol
+	})
+	return units
+}
+
+// UnknownUnitError is returned by Convert for units missing from the
+// registry.
+type UnknownUnitError struct {
+	Unit string
+}
+
+func (e *UnknownUnitError) Error() string {
+	return fmt.Sprintf("unknown unit %q", e.Unit)
+}
+
+// DimensionError is returned by Convert for units measuring different
+// quantities.
+type DimensionError struct {
+	From, To Unit
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("cannot convert %s (%s) to %s (%s)", e.From.Symbol, e.From.Dimension, e.To.Symbol, e.To.Dimension)
+}
+
+// decimal returns the shortest decimal that rounds to v, which is what
+// clients mean by, e.g., 0.1 or -459.67.
+func decimal(v float64) *big.Rat {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'g', -1, 64))
+	return r
+}
+
+// Convert converts value from one unit to another of the same dimension.
+// value is taken as the shortest decimal that rounds to it, so that, e.g.,
+// -459.67 °F is exactly 0 K. The conversion is exact and only the result
+// is rounded, to ±Inf if it overflows a float64.
+func (r *Registry) Convert(value float64, from, to string) (float64, Dimension, error) {
+	f, ok := r.Lookup(from)
+	if !ok {
+		return 0, "", &UnknownUnitError{Unit: from}
+	}
+	t, ok := r.Lookup(to)
+	if !ok {
+		return 0, "", &UnknownUnitError{Unit: to}
+	}
+	if f.Dimension != t.Dimension {
+		return 0, "", &DimensionError{From: f, To: t}
+	}
+	if f.Symbol == t.Symbol || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value, f.Dimension, nil
+	}
+
+	result, _ := t.fromBase(f.toBase(decimal(value))).Float64()
+	return result, f.Dimension, nil
+}
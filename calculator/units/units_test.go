@@ -0,0 +1,122 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestConvertExact(t *testing.T) {
+	tests := []struct {
+		value    float64
+		from, to string
+		want     float64
+	}{
+		{100, "C", "F", 212},
+		{-40, "celsius", "fahrenheit", -40},
+		{-459.67, "°F", "K", 0},
+		{0, "K", "C", -273.15},
+		{98.6, "F", "C", 37},
+		{1, "mi", "m", 1609.344},
+		{1, "mi", "ft", 5280},
+		{1, "in", "cm", 2.54},
+		{3, "ft", "yd", 1},
+		{1, "lb", "oz", 16},
+		{14, "lb", "st", 1},
+		{1, "GiB", "MiB", 1024},
+		{1, "GB", "B", 1e9},
+		{8, "Mbit", "MB", 1},
+		{1, "kn", "km/h", 1.852},
+		{36, "km/h", "m/s", 10},
+		{1, "wk", "min", 10080},
+		{0.1, "h", "s", 360},
+		{1, "Kilometers", "meter", 1000},
+	}
+	for _, tt := range tests {
+		got, _, err := Builtin.Convert(tt.value, tt.from, tt.to)
+		if err != nil || got != tt.want {
+			t.Errorf("Convert(%v %s to %s) = %v, %v, want exactly %v", tt.value, tt.from, tt.to, got, err, tt.want)
+		}
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	units := Builtin.Units()
+	for i := 0; i < 10000; i++ {
+		from, to := units[rnd.Intn(len(units))], units[rnd.Intn(len(units))]
+		if from.Dimension != to.Dimension {
+			continue
+		}
+		x := (rnd.Float64() - 0.5) * math.Pow(10, float64(rnd.Intn(20)-10))
+
+		y, _, err := Builtin.Convert(x, from.Symbol, to.Symbol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// only the result is rounded: y is the nearest float64 to the
+		// exact conversion
+		exact := to.fromBase(from.toBase(decimal(x)))
+		if want, _ := exact.Float64(); y != want {
+			t.Fatalf("Convert(%v %s to %s) = %v, want %v", x, from.Symbol, to.Symbol, y, want)
+		}
+
+		// so converting back is off by at most the roundings of y and of
+		// the result, y's scaled to the units of x
+		back, _, _ := Builtin.Convert(y, to.Symbol, from.Symbol)
+		ratio, _ := new(big.Rat).Quo(to.Scale, from.Scale).Float64()
+		if tolerance := ulp(x) + ulp(y)*ratio; math.Abs(back-x) > tolerance {
+			t.Fatalf("%v %s to %s and back = %v, off by %g, over %g", x, from.Symbol, to.Symbol, back, math.Abs(back-x), tolerance)
+		}
+	}
+}
+
+func ulp(x float64) float64 {
+	x = math.Abs(x)
+	return math.Nextafter(x, math.Inf(1)) - x
+}
+
+func TestConvertErrors(t *testing.T) {
+	var unknown *UnknownUnitError
+	if _, _, err := Builtin.Convert(1, "furlong", "m"); !errors.As(err, &unknown) || unknown.Unit != "furlong" {
+		t.Errorf("Convert from furlong = %v, want an *UnknownUnitError", err)
+	}
+	var dim *DimensionError
+	if _, _, err := Builtin.Convert(1, "kg", "m"); !errors.As(err, &dim) {
+		t.Errorf("Convert from kg to m = %v, want a *DimensionError", err)
+	}
+
+	if got, _, _ := Builtin.Convert(1e308, "km", "nm"); !math.IsInf(got, 1) {
+		t.Errorf("Convert(1e308 km to nm) = %v, want +Inf", got)
+	}
+	if got, _, _ := Builtin.Convert(math.NaN(), "C", "F"); !math.IsNaN(got) {
+		t.Errorf("Convert(NaN) = %v", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	r := NewRegistry()
+	furlong := Unit{Symbol: "fur", Names: []string{"Furlong"}, Dimension: Length, Scale: big.NewRat(201168, 1000)}
+	if err := r.Register(furlong); err != nil {
+		t.Fatal(err)
+	}
+	if u, ok := r.Lookup("FURLONG"); !ok || u.Symbol != "fur" {
+		t.Errorf("Lookup(FURLONG) = %v, %v", u, ok)
+	}
+	if _, ok := r.Lookup("FUR"); ok {
+		t.Error("symbols are case-insensitive")
+	}
+
+	for _, u := range []Unit{
+		{Symbol: "fur", Dimension: Length, Scale: big.NewRat(1, 1)},
+		{Symbol: "f2", Names: []string{"furlong"}, Dimension: Length, Scale: big.NewRat(1, 1)},
+		{Symbol: "zero", Dimension: Length, Scale: new(big.Rat)},
+		{Symbol: "nodim", Scale: big.NewRat(1, 1)},
+	} {
+		if err := r.Register(u); err == nil {
+			t.Errorf("Register(%+v) succeeded", u)
+		}
+	}
+}
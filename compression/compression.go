@@ -0,0 +1,51 @@
+// Package compression registers the message compressors of the course
+// servers and clients: gzip, zstd and snappy. Importing it is enough for a
+// server to accept compressed requests and compress its responses with the
+// compressor of the request. Clients choose a compressor per connection
+// with grpcclient.WithCompression, or per call with grpc.UseCompressor.
+package compression
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// Names of the compressors.
+const (
+	Identity = "identity"
+	Gzip     = gzip.Name
+	Zstd     = "zstd"
+	Snappy   = "snappy"
+)
+
+// MaxDecodedSize bounds the size of zstd messages once decompressed, to
+// protect against decompression bombs. gRPC enforces its own maximum
+// message size on top of it.
+const MaxDecodedSize = 64 << 20
+
+func init() {
+	encoding.RegisterCompressor(newZstdCompressor())
+	encoding.RegisterCompressor(newSnappyCompressor())
+}
+
+// Names returns the names of the supported compressors.
+func Names() []string {
+	return []string{Gzip, Zstd, Snappy}
+}
+
+// Check returns an error if name is neither a supported compressor nor
+// Identity, which disables compression.
+func Check(name string) error {
+	if name == Identity {
+		return nil
+	}
+	for _, n := range Names() {
+		if name == n {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown compressor %q, expected one of %s or %s", name, strings.Join(Names(), ", "), Identity)
+}
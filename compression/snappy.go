@@ -0,0 +1,72 @@
+package compression
+
+import (
+	"io"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+// snappyCompressor uses the snappy framing format, pooling writers and
+// readers the way grpc's gzip compressor does.
+type snappyCompressor struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+func newSnappyCompressor() *snappyCompressor {
+	return &snappyCompressor{}
+}
+
+func (c *snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	sw, ok := c.writers.Get().(*snappy.Writer)
+	if !ok {
+		sw = snappy.NewBufferedWriter(w)
+	} else {
+		sw.Reset(w)
+	}
+	return &snappyWriter{Writer: sw, pool: &c.writers}, nil
+}
+
+func (c *snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	sr, ok := c.readers.Get().(*snappy.Reader)
+	if !ok {
+		sr = snappy.NewReader(r)
+	} else {
+		sr.Reset(r)
+	}
+	return &snappyReader{Reader: sr, pool: &c.readers}, nil
+}
+
+func (c *snappyCompressor) Name() string {
+	return Snappy
+}
+
+type snappyWriter struct {
+	*snappy.Writer
+	pool *sync.Pool
+}
+
+func (w *snappyWriter) Close() error {
+	defer w.pool.Put(w.Writer)
+	return w.Writer.Close()
+}
+
+// snappyReader returns its reader to the pool once the message has been
+// read entirely.
+type snappyReader struct {
+	*snappy.Reader
+	pool *sync.Pool
+}
+
+func (r *snappyReader) Read(p []byte) (int, error) {
+	if r.Reader == nil {
+		return 0, io.EOF
+	}
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Reader)
+		r.Reader = nil
+	}
+	return n, err
+}
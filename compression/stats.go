@@ -0,0 +1,146 @@
+package compression
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/grpc/stats"
+)
+
+// MetricsVar is the name of the expvar the compression metrics are
+// published in.
+const MetricsVar = "grpc_compression"
+
+// compressionMetrics are the message sizes seen by the stats handlers, per
+// compressor, as published in the MetricsVar expvar.
+type compressionMetrics struct {
+	mu         sync.Mutex
+	compressor map[string]*compressorMetrics
+}
+
+type compressorMetrics struct {
+	messages  int64
+	bytes     int64
+	wireBytes int64
+}
+
+var (
+	metrics     = &compressionMetrics{compressor: make(map[string]*compressorMetrics)}
+	publishOnce sync.Once
+)
+
+// String renders the metrics as JSON: for each compressor, the number of
+// messages, their uncompressed and wire sizes in bytes, and the ratio of
+// the two.
+func (m *compressionMetrics) String() string {
+	type entry struct {
+		Messages  int64   `json:"messages"`
+		Bytes     int64   `json:"bytes"`
+		WireBytes int64   `json:"wire_bytes"`
+		Ratio     float64 `json:"ratio"`
+	}
+
+	m.mu.Lock()
+	out := make(map[string]entry, len(m.compressor))
+	for name, c := range m.compressor {
+		e := entry{
+			Messages:  atomic.LoadInt64(&c.messages),
+			Bytes:     atomic.LoadInt64(&c.bytes),
+			WireBytes: atomic.LoadInt64(&c.wireBytes),
+		}
+		if e.WireBytes > 0 {
+			e.Ratio = float64(e.Bytes) / float64(e.WireBytes)
+		}
+		out[name] = e
+	}
+	m.mu.Unlock()
+
+	b, _ := json.Marshal(out)
+	return string(b)
+}
+
+func (m *compressionMetrics) record(compressor string, length, wireLength int) {
+	m.mu.Lock()
+	c, ok := m.compressor[compressor]
+	if !ok {
+		c = &compressorMetrics{}
+		m.compressor[compressor] = c
+	}
+	m.mu.Unlock()
+
+	atomic.AddInt64(&c.messages, 1)
+	atomic.AddInt64(&c.bytes, int64(length))
+	atomic.AddInt64(&c.wireBytes, int64(wireLength))
+}
+
+// StatsHandler is a stats.Handler recording the size of messages before
+// and after compression. Wire sizes include the 5 byte gRPC message header,
+// so uncompressed messages show a ratio slightly below 1.
+type StatsHandler struct{}
+
+// NewStatsHandler returns a stats handler and publishes the metrics in the
+// MetricsVar expvar.
+func NewStatsHandler() *StatsHandler {
+	publishOnce.Do(func() {
+		expvar.Publish(MetricsVar, metrics)
+	})
+	return &StatsHandler{}
+}
+
+type rpcKey struct{}
+
+// rpcCompression is the compressor of an RPC, known once its headers are
+// received or sent.
+type rpcCompression struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (r *rpcCompression) set(name string) {
+	if name == "" {
+		return
+	}
+	r.mu.Lock()
+	r.name = name
+	r.mu.Unlock()
+}
+
+func (r *rpcCompression) get() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.name == "" {
+		return Identity
+	}
+	return r.name
+}
+
+func (h *StatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, rpcKey{}, &rpcCompression{})
+}
+
+func (h *StatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	r, ok := ctx.Value(rpcKey{}).(*rpcCompression)
+	if !ok {
+		return
+	}
+
+	switch s := s.(type) {
+	case *stats.InHeader:
+		r.set(s.Compression)
+	case *stats.OutHeader:
+		r.set(s.Compression)
+	case *stats.InPayload:
+		metrics.record(r.get(), s.Length, s.WireLength)
+	case *stats.OutPayload:
+		metrics.record(r.get(), s.Length, s.WireLength)
+	}
+}
+
+func (h *StatsHandler) TagConn(ctx context.Context, info *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *StatsHandler) HandleConn(ctx context.Context, s stats.ConnStats) {}
@@ -0,0 +1,76 @@
+package compression
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdCompressor compresses whole messages with a shared encoder and
+// decoder, which are safe for concurrent use in that mode and, unlike
+// streaming ones, do not keep goroutines around.
+type zstdCompressor struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func newZstdCompressor() *zstdCompressor {
+	return &zstdCompressor{}
+}
+
+func (c *zstdCompressor) init() error {
+	c.once.Do(func() {
+		if c.encoder, c.err = zstd.NewWriter(nil); c.err != nil {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecodedSize))
+	})
+	return c.err
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return &zstdWriter{c: c, w: w}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b, err := c.decoder.DecodeAll(src, nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+// zstdWriter buffers a message and compresses it on Close.
+type zstdWriter struct {
+	c   *zstdCompressor
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) {
+	return z.buf.Write(p)
+}
+
+func (z *zstdWriter) Close() error {
+	_, err := z.w.Write(z.c.encoder.EncodeAll(z.buf.Bytes(), nil))
+	return err
+}
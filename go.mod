@@ -3,8 +3,13 @@ module github.com/rsorage/grpc-go-course
 go 1.16
 
 require (
+	github.com/cespare/xxhash v1.1.0 // indirect
+	github.com/golang/snappy v0.0.1
+	github.com/klauspost/compress v1.9.5
 	go.mongodb.org/mongo-driver v1.7.2
-	golang.org/x/text v0.3.5 // indirect
-	google.golang.org/grpc v1.40.0
+	golang.org/x/text v0.3.5
+	google.golang.org/genproto v0.0.0-20200526211855-cb27e3aa2013
+	google.golang.org/grpc v1.43.0
 	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,309 @@
+// Package chat implements chat rooms: every message said in a room is
+// delivered to all of its members, along with presence events when members
+// join or leave. Members receive the recent history of a room when they join
+// it.
+package chat
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Defaults of NewHub.
+const (
+	DefaultHistory = 50
+	DefaultBuffer  = 64
+)
+
+// ValidRoom reports whether name is a valid room name: at most 64 bytes of
+// letters, digits, '-', '_' and '.'.
+func ValidRoom(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if r != '-' && r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Kind is the kind of an event.
+type Kind int
+
+const (
+	// Message is a text said by a member.
+	Message Kind = iota + 1
+	// Joined is sent when a member joins the room.
+	Joined
+	// Left is sent when a member leaves the room or is disconnected.
+	Left
+)
+
+// Event is something that happened in a room.
+type Event struct {
+	// Seq numbers the events of a room from 1.
+	Seq  int64
+	Time time.Time
+	Kind Kind
+	// From is the member the event is about.
+	From string
+	// Text is the message of Message events.
+	Text string
+	// Members are the names of the members of the room after a Joined or
+	// Left event, sorted.
+	Members []string
+}
+
+// ErrSlowConsumer is the reason members who do not keep up with the
+// events of their room are disconnected.
+var ErrSlowConsumer = errors.New("too slow to keep up with the room")
+
+// ErrLeft is returned when saying something after leaving the room.
+var ErrLeft = errors.New("left the room")
+
+// ErrNameTaken is returned when joining a room that already has a member
+// with the same name.
+var ErrNameTaken = errors.New("name already taken in the room")
+
+// Hub holds the rooms. Rooms are created when the first member joins and
+// deleted, along with their history, when the last one leaves.
+type Hub struct {
+	history int
+	buffer  int
+
+	mu    sync.Mutex
+	rooms map[string]*room
+}
+
+// Option configures NewHub.
+type Option func(*Hub)
+
+// WithHistory keeps the last n events of each room for replay on join.
+func WithHistory(n int) Option {
+	return func(h *Hub) {
+		h.history = n
+	}
+}
+
+// WithBuffer lets members fall up to n events behind before they are
+// disconnected as slow consumers.
+func WithBuffer(n int) Option {
+	return func(h *Hub) {
+		h.buffer = n
+	}
+}
+
+// NewHub creates a hub without rooms.
+func NewHub(opts ...Option) *Hub {
+	h := &Hub{
+		history: DefaultHistory,
+		buffer:  DefaultBuffer,
+		rooms:   make(map[string]*room),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.buffer < 1 {
+		h.buffer = 1
+	}
+	if h.history < 0 {
+		h.history = 0
+	}
+	return h
+}
+
+type room struct {
+	name    string
+	members map[*Member]bool
+	history []Event
+	seq     int64
+}
+
+// Member is the membership of someone in a room.
+type Member struct {
+	Room string
+	Name string
+
+	hub  *Hub
+	room *room
+	// queue holds the events not yet taken by deliver, at most hub.buffer.
+	// changed is signaled, with hub.mu as its lock, when the queue changes
+	// or the member is removed.
+	queue   []Event
+	changed *sync.Cond
+	events  chan Event
+	done    chan struct{}
+	err     error
+}
+
+// Join adds a member called name to a room. It returns the member and the
+// history of the room before the member joined; the member's own Joined
+// event is the first one it receives. It fails with ErrNameTaken if the
+// room already has a member called name.
+func (h *Hub) Join(roomName, name string) (*Member, []Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rooms[roomName]
+	if !ok {
+		r = &room{name: roomName, members: make(map[*Member]bool)}
+		h.rooms[roomName] = r
+	}
+	for other := range r.members {
+		if other.Name == name {
+			return nil, nil, ErrNameTaken
+		}
+	}
+	history := append([]Event(nil), r.history...)
+
+	m := &Member{
+		Room:    roomName,
+		Name:    name,
+		hub:     h,
+		room:    r,
+		changed: sync.NewCond(&h.mu),
+		events:  make(chan Event),
+		done:    make(chan struct{}),
+	}
+	r.members[m] = true
+	go m.deliver()
+	h.publish(r, Event{Kind: Joined, From: name})
+
+	return m, history, nil
+}
+
+// deliver moves the queued events of m to its events channel until m is
+// removed.
+func (m *Member) deliver() {
+	h := m.hub
+	for {
+		h.mu.Lock()
+		for len(m.queue) == 0 && m.room.members[m] {
+			m.changed.Wait()
+		}
+		if !m.room.members[m] {
+			h.mu.Unlock()
+			return
+		}
+		ev := m.queue[0]
+		m.queue[0] = Event{}
+		m.queue = m.queue[1:]
+		m.changed.Broadcast()
+		h.mu.Unlock()
+
+		select {
+		case m.events <- ev:
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Events returns the events of the room.
+func (m *Member) Events() <-chan Event {
+	return m.events
+}
+
+// Done is closed when the member leaves or is disconnected.
+func (m *Member) Done() <-chan struct{} {
+	return m.done
+}
+
+// Err returns why the member is no longer in the room, once Done is closed.
+func (m *Member) Err() error {
+	m.hub.mu.Lock()
+	defer m.hub.mu.Unlock()
+	return m.err
+}
+
+// Say sends a message to every member of the room, including m. It waits
+// while m's own buffer is more than half full, so that members who talk
+// faster than they listen are slowed down rather than disconnected.
+func (m *Member) Say(text string) error {
+	m.hub.mu.Lock()
+	defer m.hub.mu.Unlock()
+
+	for m.room.members[m] && len(m.queue) > m.hub.buffer/2 {
+		m.changed.Wait()
+	}
+	if !m.room.members[m] {
+		return m.err
+	}
+	m.hub.publish(m.room, Event{Kind: Message, From: m.Name, Text: text})
+	return nil
+}
+
+// Leave removes the member from the room. It is safe to call more than
+// once.
+func (m *Member) Leave() {
+	m.hub.mu.Lock()
+	defer m.hub.mu.Unlock()
+
+	if m.room.members[m] {
+		m.hub.remove(m, ErrLeft)
+	}
+}
+
+// remove takes m out of its room and announces it. It must be called with
+// h.mu held.
+func (h *Hub) remove(m *Member, err error) {
+	r := m.room
+	delete(r.members, m)
+	m.err = err
+	close(m.done)
+	m.changed.Broadcast()
+
+	if len(r.members) == 0 {
+		delete(h.rooms, r.name)
+		return
+	}
+	h.publish(r, Event{Kind: Left, From: m.Name})
+}
+
+// publish records ev in the history of r and delivers it to its members.
+// Members whose buffer is full are disconnected, which is announced in turn.
+// It must be called with h.mu held.
+func (h *Hub) publish(r *room, ev Event) {
+	r.seq++
+	ev.Seq = r.seq
+	ev.Time = time.Now()
+	if ev.Kind != Message {
+		ev.Members = r.names()
+	}
+
+	if h.history > 0 {
+		if len(r.history) == h.history {
+			r.history = append(r.history[:0], r.history[1:]...)
+		}
+		r.history = append(r.history, ev)
+	}
+
+	var slow []*Member
+	for m := range r.members {
+		if len(m.queue) == h.buffer {
+			slow = append(slow, m)
+			continue
+		}
+		m.queue = append(m.queue, ev)
+		m.changed.Broadcast()
+	}
+	for _, m := range slow {
+		if r.members[m] {
+			h.remove(m, ErrSlowConsumer)
+		}
+	}
+}
+
+func (r *room) names() []string {
+	names := make([]string, 0, len(r.members))
+	for m := range r.members {
+		names = append(names, m.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,161 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func join(t *testing.T, h *Hub, room, name string) *Member {
+	t.Helper()
+	m, _, err := h.Join(room, name)
+	if err != nil {
+		t.Fatalf("Join(%q, %q) = %v", room, name, err)
+	}
+	return m
+}
+
+func next(t *testing.T, m *Member) Event {
+	t.Helper()
+	select {
+	case ev := <-m.Events():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatalf("%s received no event", m.Name)
+		return Event{}
+	}
+}
+
+func TestJoinNameTaken(t *testing.T) {
+	h := NewHub()
+	a := join(t, h, "lobby", "Ann")
+	if _, _, err := h.Join("lobby", "Ann"); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("joining twice as Ann = %v, want ErrNameTaken", err)
+	}
+	join(t, h, "other", "Ann")
+
+	a.Leave()
+	join(t, h, "lobby", "Ann")
+}
+
+func TestEventsAndHistory(t *testing.T) {
+	h := NewHub(WithHistory(2))
+	a := join(t, h, "lobby", "Ann")
+	if ev := next(t, a); ev.Kind != Joined || ev.From != "Ann" || ev.Seq != 1 {
+		t.Errorf("first event = %+v, want Ann's Joined", ev)
+	}
+	if err := a.Say("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if ev := next(t, a); ev.Kind != Message || ev.Text != "hi" {
+		t.Errorf("event = %+v, want hi", ev)
+	}
+
+	b, history, err := h.Join("lobby", "Bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[1].Text != "hi" {
+		t.Errorf("history = %+v, want the last 2 events", history)
+	}
+	if ev := next(t, b); ev.Kind != Joined || len(ev.Members) != 2 {
+		t.Errorf("Bob's first event = %+v, want his Joined with 2 members", ev)
+	}
+
+	b.Leave()
+	if ev := next(t, a); ev.From != "Bob" {
+		t.Errorf("event = %+v, want Bob's Joined", ev)
+	}
+	if ev := next(t, a); ev.Kind != Left || ev.From != "Bob" {
+		t.Errorf("event = %+v, want Bob's Left", ev)
+	}
+	if err := b.Say("bye"); err != ErrLeft {
+		t.Errorf("Say after leaving = %v, want ErrLeft", err)
+	}
+}
+
+func TestSayWaitsForDrain(t *testing.T) {
+	const buffer, messages = 4, 20
+	h := NewHub(WithBuffer(buffer))
+	a := join(t, h, "lobby", "Ann")
+
+	said := make(chan int, messages)
+	go func() {
+		defer close(said)
+		for i := 0; i < messages; i++ {
+			if err := a.Say("x"); err != nil {
+				t.Errorf("Say() = %v", err)
+				return
+			}
+			said <- i
+		}
+	}()
+
+	// Ann does not listen, so her own messages fill her buffer and she is
+	// held back instead of disconnected
+	time.Sleep(50 * time.Millisecond)
+	if n := len(said); n == 0 || n > buffer/2+1 {
+		t.Fatalf("%d messages said before Ann listens, want 1 to %d", n, buffer/2+1)
+	}
+
+	// her Joined event and every message
+	for i := 0; i < messages+1; i++ {
+		next(t, a)
+	}
+	for range said {
+	}
+	select {
+	case <-a.Done():
+		t.Fatalf("disconnected: %v", a.Err())
+	default:
+	}
+}
+
+func TestSlowConsumer(t *testing.T) {
+	h := NewHub(WithBuffer(2))
+	a := join(t, h, "lobby", "Ann")
+	b := join(t, h, "lobby", "Bob")
+	next(t, a)
+	next(t, a)
+
+	// Ann keeps up, Bob does not read at all
+	for i := 0; i < 4; i++ {
+		if err := a.Say("x"); err != nil {
+			t.Fatal(err)
+		}
+		next(t, a)
+	}
+	select {
+	case <-b.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Bob was not disconnected")
+	}
+	if err := b.Err(); err != ErrSlowConsumer {
+		t.Errorf("Bob's Err() = %v, want ErrSlowConsumer", err)
+	}
+}
+
+func TestLeaveWakesSay(t *testing.T) {
+	h := NewHub(WithBuffer(2))
+	a := join(t, h, "lobby", "Ann")
+
+	done := make(chan error)
+	go func() {
+		for {
+			if err := a.Say("x"); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	a.Leave()
+	select {
+	case err := <-done:
+		if err != ErrLeft {
+			t.Errorf("Say() = %v, want ErrLeft", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Say still waiting after Leave")
+	}
+}
@@ -1,243 +1,191 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"flag"
+	"fmt"
 	"io"
 	"log"
-	"time"
+	"os"
+	"os/signal"
+	"strings"
 
+	"github.com/rsorage/grpc-go-course/compression"
 	"github.com/rsorage/grpc-go-course/greet/greetpb"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
+	"github.com/rsorage/grpc-go-course/grpcclient"
+	"github.com/rsorage/grpc-go-course/heartbeat"
 )
 
-func main() {
-
-	tls := false
-	opts := grpc.WithInsecure()
-	if tls {
-		certFile := "ssl/ca.crt"
-		creds, sslErr := credentials.NewClientTLSFromFile(certFile, "")
-		if sslErr != nil {
-			log.Fatalf("Error while loading CA trust certificate: %v", sslErr)
-			return
-		}
-		opts = grpc.WithTransportCredentials(creds)
-	}
-
-	cc, err := grpc.Dial("localhost:50051", opts)
-	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
-	}
-
-	defer cc.Close()
+const usage = `Usage: greet [flags] <command> [names...]
 
-	c := greetpb.NewGreetServiceClient(cc)
+Commands:
+  greet      unary Greet for each name, with -template if given
+  many       server streaming GreetManyTimes for each name, honoring -count
+             and -interval and resuming the stream if the server goes away
+  long       client streaming LongGreet with all names
+  everyone   bidirectional streaming GreetEveryone with all names
+  deadline   unary GreetWithDeadline for each name, honoring -deadline
+  room       joins the -room chat room as the first name, saying each line
+             read from the standard input
 
-	// doUnary(c)
-	// doServerStreaming(c)
-	// doClientStreaming(c)
-	doBiDiStreaming(c)
-	// doUnaryWithDeadline(c, 5*time.Second)
-	// doUnaryWithDeadline(c, 1*time.Second)
-}
+Names are "First Last" strings given as arguments or, with -names-file,
+one per line.
 
-func doUnary(c greetpb.GreetServiceClient) {
+Flags:
+`
 
-	request := &greetpb.GreetRequest{
-		Greeting: &greetpb.Greeting{
-			FirstName: "Ramon",
-			LastName:  "Sorage",
-		},
+func main() {
+	log.SetFlags(0)
+
+	addr := flag.String("addr", "localhost:50051", "GreetService address")
+	tls := flag.Bool("tls", false, "enable TLS")
+	caFile := flag.String("ca", "ssl/ca.crt", "CA trust certificate used with -tls")
+	namesFile := flag.String("names-file", "", "file with one name per line")
+	deadline := flag.Duration("deadline", 0, "deadline of each call (0 for none)")
+	concurrency := flag.Int("concurrency", 0, "run the command with N concurrent workers as a load test")
+	requests := flag.Int("requests", 100, "total number of commands run by the load test")
+	locale := flag.String("locale", "", "language of the greetings, e.g. pt-BR (default from $LANG)")
+	formal := flag.Bool("formal", false, "greet by the full name")
+	count := flag.Int("count", 0, "number of greetings of each many stream (0 for the server default)")
+	interval := flag.Duration("interval", 0, "pause between the greetings of many (0 for the server default)")
+	hbInterval := flag.Duration("heartbeat", heartbeat.DefaultInterval, "ping interval of the everyone and room streams (0 disables)")
+	keepaliveTime := flag.Duration("keepalive", grpcclient.DefaultKeepalive.Time, "idle time after which the connection is pinged (0 disables)")
+	compressor := flag.String("compression", compression.Identity, "compressor of every call: identity, gzip, zstd or snappy")
+	room := flag.String("room", "lobby", "chat room joined by room")
+	template := flag.String("template", "", "name of the server-side greeting template used by greet")
+	timeZone := flag.String("tz", "", "IANA time zone of the recipients for -template (default the server's)")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	name, args := flag.Arg(0), flag.Args()[1:]
+	cmd, ok := commands[name]
+	if !ok {
+		log.Printf("Unknown command %q\n\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *namesFile != "" {
+		fileNames, err := readNames(*namesFile)
+		if err != nil {
+			log.Fatalf("Reading names: %v", err)
+		}
+		args = append(args, fileNames...)
 	}
-
-	ret, err := c.Greet(context.Background(), request)
-	if err != nil {
-		log.Fatalf("Unable to greet: %v", err)
+	if len(args) == 0 {
+		log.Fatalf("%s: no names given", name)
 	}
-	log.Println("Response from Greet: ", ret.Result)
-}
-
-func doServerStreaming(c greetpb.GreetServiceClient) {
 
-	request := &greetpb.GreetManyTimesRequest{
-		Greeting: &greetpb.Greeting{
-			FirstName: "Ramon",
-			LastName:  "Sorage",
-		},
+	if *locale == "" {
+		*locale = localeFromEnv()
 	}
 
-	stream, err := c.GreetManyTimes(context.Background(), request)
-	if err != nil {
-		log.Fatalf("Unable to greet many times: %v", err)
+	var greetings []*greetpb.Greeting
+	for _, n := range args {
+		g := parseName(n)
+		g.Locale = *locale
+		g.Formal = *formal
+		greetings = append(greetings, g)
 	}
 
-	for {
-		msg, err := stream.Recv()
-		if err == io.EOF {
-			// server closed stream
-			break
-		}
-		if err != nil {
-			log.Fatalf("Error while reading stream: %v", err)
-		}
-		log.Printf("Response from GreetManyTimes: %v", msg.GetResult())
+	opts := []grpcclient.Option{
+		grpcclient.WithKeepalive(*keepaliveTime, grpcclient.DefaultKeepalive.Timeout, false),
+		grpcclient.WithCompression(*compressor),
+	}
+	if *tls {
+		opts = append(opts, grpcclient.WithTLS(*caFile))
 	}
-}
 
-func doClientStreaming(c greetpb.GreetServiceClient) {
-	log.Println("Starting to do a ClientStreaming RPC...")
-
-	requests := []*greetpb.LongGreetRequest{
-		&greetpb.LongGreetRequest{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Stephane",
-			},
-		},
-		&greetpb.LongGreetRequest{
-			Greeting: &greetpb.Greeting{
-				FirstName: "John",
-			},
-		},
-		&greetpb.LongGreetRequest{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Lucy",
-			},
-		},
-		&greetpb.LongGreetRequest{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Mark",
-			},
-		},
-		&greetpb.LongGreetRequest{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Piper",
-			},
-		},
-	}
-
-	stream, err := c.LongGreet(context.Background())
+	cc, err := grpcclient.Dial(*addr, opts...)
 	if err != nil {
-		log.Fatalf("Error while sending stream to LongGreet service: %v", err)
-		return
+		log.Fatalf("Failed to connect: %v", err)
 	}
+	defer cc.Close()
 
-	for _, req := range requests {
-		log.Printf("Streaming req: %v", req)
-		stream.Send(req)
-		time.Sleep(100 * time.Millisecond)
+	g := &greeter{
+		c:        greetpb.NewGreetServiceClient(cc),
+		in:       os.Stdin,
+		out:      os.Stdout,
+		deadline: *deadline,
+		count:    *count,
+		interval: *interval,
+		room:     *room,
+		template: *template,
+		timeZone: *timeZone,
+
+		heartbeat: *hbInterval,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *concurrency > 0 {
+		g.out = io.Discard
+		report := runLoad(ctx, *concurrency, *requests, func(ctx context.Context) error {
+			return cmd(g, ctx, greetings)
+		})
+		report.print(os.Stdout)
+		return
 	}
 
-	res, err := stream.CloseAndRecv()
-	if err != nil {
-		log.Fatalf("Error while receiving LongGreet response: %v", err)
+	if err := cmd(g, ctx, greetings); err != nil {
+		log.Fatalf("%s: %v", name, err)
 	}
-
-	log.Printf("Received LongGreet response: %v", res)
 }
 
-func doBiDiStreaming(c greetpb.GreetServiceClient) {
-	log.Println("Starting to do BiDi Streaming...")
-
-	requests := []*greetpb.GreetEveryoneRequest{
-		{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Ramon",
-			},
-		},
-		{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Juliana",
-			},
-		},
-		{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Matteus",
-			},
-		},
-		{
-			Greeting: &greetpb.Greeting{
-				FirstName: "Sandra",
-			},
-		},
-	}
-
-	stream, err := c.GreetEveryone(context.Background())
-
-	if err == io.EOF {
-		log.Printf("Server closed bidi streaming!")
-		return
+// parseName splits "First Last" into a greeting. Everything after the
+// first word is the last name.
+func parseName(name string) *greetpb.Greeting {
+	fields := strings.Fields(name)
+	g := &greetpb.Greeting{}
+	if len(fields) > 0 {
+		g.FirstName = fields[0]
+		g.LastName = strings.Join(fields[1:], " ")
 	}
+	return g
+}
+
+func readNames(path string) ([]string, error) {
+	f, err := os.Open(path)
 	if err != nil {
-		log.Fatalf("Error opening streaming with server: %v\n", err)
-		return
+		return nil, err
 	}
+	defer f.Close()
 
-	ch := make(chan string)
-
-	go func() {
-		for {
-			res, err := stream.Recv()
-			if err == io.EOF {
-				log.Printf("Streaming closed by server!")
-				break
-			}
-			if err != nil {
-				log.Fatalf("Error receiving message from server: %v\n", err)
-				break
-			}
-			ch <- res.GetResult()
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			names = append(names, line)
 		}
-		close(ch)
-	}()
-
-	go func() {
-		for _, req := range requests {
-			if err := stream.Send(req); err != nil {
-				log.Fatalf("Error while sending message to server: %v\n", err)
-				break
-			}
-			time.Sleep(500 * time.Millisecond)
-			log.Printf("Sent greeting to %s", req.GetGreeting().GetFirstName())
-		}
-
-		stream.CloseSend()
-	}()
-
-	for msg := range ch {
-		log.Printf("Received from server: %v", msg)
 	}
+	return names, scanner.Err()
 }
 
-func doUnaryWithDeadline(c greetpb.GreetServiceClient, timeout time.Duration) {
-	log.Println("Starting to do unary with deadline RPC...")
-	req := &greetpb.GreetWithDeadlineRequest{
-		Greeting: &greetpb.Greeting{
-			FirstName: "Ramon",
-			LastName:  "Sorage",
-		},
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
-	res, err := c.GreetWithDeadline(ctx, req)
-	if err != nil {
-		statusErr, ok := status.FromError(err)
-
-		if ok {
-			if statusErr.Code() == codes.DeadlineExceeded {
-				log.Println("Timeout was hit! Deadline exceeded...")
-			} else {
-				log.Printf("Unexpected error: %v\n", statusErr)
-			}
-		} else {
-			log.Fatalf("Error while calling GreetWithDeadline RPC: %v", err)
+// localeFromEnv returns the language of the POSIX locale environment, e.g.
+// "pt-BR" for LANG=pt_BR.UTF-8, or "" if it is unset or "C".
+func localeFromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
 		}
-		return
+		if i := strings.IndexAny(v, ".@"); i >= 0 {
+			v = v[:i]
+		}
+		if v == "C" || v == "POSIX" {
+			return ""
+		}
+		return strings.Replace(v, "_", "-", 1)
 	}
-
-	log.Printf("Response from GreetWithDeadline: %v", res.Result)
+	return ""
 }
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/heartbeat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// greeter runs the CLI commands against a GreetService.
+type greeter struct {
+	c        greetpb.GreetServiceClient
+	out      io.Writer
+	deadline time.Duration
+	count    int
+	interval time.Duration
+	room     string
+	template string
+	timeZone string
+	in       io.Reader
+
+	heartbeat time.Duration
+}
+
+type command func(g *greeter, ctx context.Context, greetings []*greetpb.Greeting) error
+
+var commands = map[string]command{
+	"greet":    (*greeter).greet,
+	"many":     (*greeter).greetManyTimes,
+	"long":     (*greeter).longGreet,
+	"everyone": (*greeter).greetEveryone,
+	"deadline": (*greeter).greetWithDeadline,
+	"room":     (*greeter).joinRoom,
+}
+
+// withDeadline applies the -deadline flag to ctx.
+func (g *greeter) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if g.deadline <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, g.deadline)
+}
+
+func (g *greeter) greet(ctx context.Context, greetings []*greetpb.Greeting) error {
+	for _, greeting := range greetings {
+		ctx, cancel := g.withDeadline(ctx)
+		res, err := g.c.Greet(ctx, &greetpb.GreetRequest{
+			Greeting: greeting,
+			Template: g.template,
+			TimeZone: g.timeZone,
+		})
+		cancel()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, res.GetResult())
+	}
+	return nil
+}
+
+func (g *greeter) greetManyTimes(ctx context.Context, greetings []*greetpb.Greeting) error {
+	for _, greeting := range greetings {
+		if err := g.greetManyTimesOne(ctx, greeting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxResumes is how many times an interrupted GreetManyTimes stream is
+// resumed before giving up.
+const maxResumes = 3
+
+func (g *greeter) greetManyTimesOne(ctx context.Context, greeting *greetpb.Greeting) error {
+	ctx, cancel := g.withDeadline(ctx)
+	defer cancel()
+
+	req := &greetpb.GreetManyTimesRequest{
+		Greeting: greeting,
+		Count:    int32(g.count),
+	}
+	if g.interval > 0 {
+		req.Interval = durationpb.New(g.interval)
+	}
+
+	for resumes := 0; ; resumes++ {
+		err := g.receiveGreetings(ctx, req)
+		if status.Code(err) != codes.Unavailable || resumes == maxResumes {
+			return err
+		}
+		fmt.Fprintf(g.out, "Stream interrupted, resuming at %d: %v\n", req.StartSequence, err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(resumes+1) * time.Second):
+		}
+	}
+}
+
+// receiveGreetings prints the greetings of a GreetManyTimes stream,
+// advancing req.StartSequence past each one received.
+func (g *greeter) receiveGreetings(ctx context.Context, req *greetpb.GreetManyTimesRequest) error {
+	stream, err := g.c.GreetManyTimes(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, res.GetResult())
+		req.StartSequence = res.GetSequence() + 1
+	}
+}
+
+func (g *greeter) longGreet(ctx context.Context, greetings []*greetpb.Greeting) error {
+	ctx, cancel := g.withDeadline(ctx)
+	defer cancel()
+
+	stream, err := g.c.LongGreet(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, greeting := range greetings {
+		if err := stream.Send(&greetpb.LongGreetRequest{Greeting: greeting}); err != nil {
+			break // the actual error is returned by CloseAndRecv
+		}
+	}
+
+	res, err := stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(g.out, res.GetResult())
+	return nil
+}
+
+func (g *greeter) greetEveryone(ctx context.Context, greetings []*greetpb.Greeting) error {
+	ctx, cancel := g.withDeadline(ctx)
+	defer cancel()
+
+	stream, err := g.c.GreetEveryone(ctx)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	send := func(req *greetpb.GreetEveryoneRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(req)
+	}
+	w := g.heartbeats(ctx, func() (interface{}, error) { return stream.Recv() }, func(hb *greetpb.Heartbeat) error {
+		return send(&greetpb.GreetEveryoneRequest{Heartbeat: hb})
+	})
+
+	go func() {
+		for _, greeting := range greetings {
+			if err := send(&greetpb.GreetEveryoneRequest{Greeting: greeting}); err != nil {
+				return // the actual error is returned by Recv
+			}
+		}
+		mu.Lock()
+		stream.CloseSend()
+		mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-w.Messages():
+			if res := msg.(*greetpb.GreetEveryoneResponse); res.GetHeartbeat() == nil {
+				fmt.Fprintln(g.out, res.GetResult())
+			}
+		case err := <-w.Err():
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (g *greeter) greetWithDeadline(ctx context.Context, greetings []*greetpb.Greeting) error {
+	for _, greeting := range greetings {
+		ctx, cancel := g.withDeadline(ctx)
+		res, err := g.c.GreetWithDeadline(ctx, &greetpb.GreetWithDeadlineRequest{Greeting: greeting})
+		cancel()
+		if status.Code(err) == codes.DeadlineExceeded {
+			return fmt.Errorf("deadline of %v exceeded", g.deadline)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, res.GetResult())
+	}
+	return nil
+}
+
+func (g *greeter) joinRoom(ctx context.Context, greetings []*greetpb.Greeting) error {
+	ctx, cancel := g.withDeadline(ctx)
+	defer cancel()
+
+	stream, err := g.c.JoinRoom(ctx)
+	if err != nil {
+		return err
+	}
+
+	join := &greetpb.JoinRoomRequest{
+		Request: &greetpb.JoinRoomRequest_Join{Join: &greetpb.JoinRoomOptions{
+			Room:     g.room,
+			Greeting: greetings[0],
+		}},
+	}
+	if err := stream.Send(join); err != nil {
+		_, err = stream.Recv()
+		return err
+	}
+
+	var mu sync.Mutex
+	send := func(req *greetpb.JoinRoomRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(req)
+	}
+	w := g.heartbeats(ctx, func() (interface{}, error) { return stream.Recv() }, func(hb *greetpb.Heartbeat) error {
+		return send(&greetpb.JoinRoomRequest{Request: &greetpb.JoinRoomRequest_Heartbeat{Heartbeat: hb}})
+	})
+
+	go func() {
+		scanner := bufio.NewScanner(g.in)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				err := send(&greetpb.JoinRoomRequest{
+					Request: &greetpb.JoinRoomRequest_Text{Text: line},
+				})
+				if err != nil {
+					return // the actual error is returned by Recv
+				}
+			}
+		}
+		mu.Lock()
+		stream.CloseSend()
+		mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-w.Messages():
+			if res := msg.(*greetpb.JoinRoomResponse); res.GetHeartbeat() == nil {
+				printEvent(g.out, res)
+			}
+		case err := <-w.Err():
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// heartbeats watches the messages received with recv and, unless disabled
+// with -heartbeat=0, pings the server with send until ctx is done. The
+// stream fails with UNAVAILABLE if the server stops answering.
+func (g *greeter) heartbeats(ctx context.Context, recv func() (interface{}, error), send func(*greetpb.Heartbeat) error) *heartbeat.Watcher {
+	if g.heartbeat <= 0 {
+		return heartbeat.Watch(ctx, recv, 0)
+	}
+
+	interval := durationpb.New(g.heartbeat)
+	go heartbeat.Ping(ctx, g.heartbeat, func(id int64) error {
+		return send(&greetpb.Heartbeat{Id: id, Interval: interval})
+	})
+	return heartbeat.Watch(ctx, recv, heartbeat.Timeout(g.heartbeat))
+}
+
+func printEvent(w io.Writer, ev *greetpb.JoinRoomResponse) {
+	prefix := ev.GetTime().AsTime().Local().Format("15:04:05")
+	if ev.GetReplayed() {
+		prefix += " (history)"
+	}
+
+	switch ev.GetPresence().GetKind() {
+	case greetpb.Presence_JOINED:
+		fmt.Fprintf(w, "%s * %s joined; here: %s\n", prefix, ev.GetFrom(), strings.Join(ev.GetPresence().GetMembers(), ", "))
+	case greetpb.Presence_LEFT:
+		fmt.Fprintf(w, "%s * %s left; here: %s\n", prefix, ev.GetFrom(), strings.Join(ev.GetPresence().GetMembers(), ", "))
+	default:
+		fmt.Fprintf(w, "%s <%s> %s\n", prefix, ev.GetFrom(), ev.GetText())
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loadReport summarizes a load test run.
+type loadReport struct {
+	elapsed   time.Duration
+	latencies []time.Duration
+	errors    map[codes.Code]int
+}
+
+// runLoad calls fn requests times from concurrency workers and measures the
+// latency of each call.
+func runLoad(ctx context.Context, concurrency, requests int, fn func(context.Context) error) *loadReport {
+	report := &loadReport{errors: make(map[codes.Code]int)}
+	var mu sync.Mutex
+
+	jobs := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		for i := 0; i < requests; i++ {
+			select {
+			case jobs <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				t := time.Now()
+				err := fn(ctx)
+				d := time.Since(t)
+
+				mu.Lock()
+				report.latencies = append(report.latencies, d)
+				if err != nil {
+					report.errors[status.Code(err)]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	report.elapsed = time.Since(start)
+
+	return report
+}
+
+func (r *loadReport) print(w io.Writer) {
+	n := len(r.latencies)
+	fmt.Fprintf(w, "Requests:   %d in %v (%.1f/s)\n", n, r.elapsed.Round(time.Millisecond), float64(n)/r.elapsed.Seconds())
+
+	failed := 0
+	for _, count := range r.errors {
+		failed += count
+	}
+	fmt.Fprintf(w, "Succeeded:  %d\n", n-failed)
+	for code, count := range r.errors {
+		fmt.Fprintf(w, "Failed:     %d (%s)\n", count, code)
+	}
+
+	if n == 0 {
+		return
+	}
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+	fmt.Fprintf(w, "Latency:    min %v, p50 %v, p90 %v, p99 %v, max %v\n",
+		r.latencies[0], r.percentile(50), r.percentile(90), r.percentile(99), r.latencies[n-1])
+}
+
+func (r *loadReport) percentile(p int) time.Duration {
+	i := (len(r.latencies)*p + 99) / 100
+	if i > 0 {
+		i--
+	}
+	return r.latencies[i]
+}
@@ -0,0 +1,167 @@
+package greetserver
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"github.com/rsorage/grpc-go-course/greet/chat"
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/heartbeat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxChatText is the maximum size in bytes of a chat message.
+const maxChatText = 4 << 10
+
+func (s *Server) JoinRoom(stream greetpb.GreetService_JoinRoomServer) error {
+	ctx := stream.Context()
+
+	req, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	join := req.GetJoin()
+	if join == nil {
+		return status.Error(codes.InvalidArgument, "The first message must join a room")
+	}
+	if !chat.ValidRoom(join.GetRoom()) {
+		return status.Errorf(codes.InvalidArgument, "Invalid room name %q: use at most 64 letters, digits, '-', '_' and '.'", join.GetRoom())
+	}
+	g := join.GetGreeting()
+	l, err := localizer(ctx, g)
+	if err != nil {
+		return err
+	}
+	name := l.Name(g.GetFirstName(), g.GetLastName(), g.GetFormal())
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "A name is required to join a room")
+	}
+
+	m, history, err := s.rooms.Join(join.GetRoom(), name)
+	if err == chat.ErrNameTaken {
+		return status.Errorf(codes.AlreadyExists, "%s is already in room %s", name, join.GetRoom())
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "Joining room %s: %v", join.GetRoom(), err)
+	}
+	defer m.Leave()
+	log.Printf("%s joined room %s", name, join.GetRoom())
+
+	for _, ev := range history {
+		if err := stream.Send(toPbEvent(ev, true)); err != nil {
+			return err
+		}
+	}
+
+	// texts are said on another goroutine, since saying waits for this one
+	// to catch up with the events of a chatty member
+	w := heartbeat.Watch(ctx, func() (interface{}, error) { return stream.Recv() }, 0)
+	pongs := make(chan int64)
+	recvErr := make(chan error, 1)
+	go func() {
+		recvErr <- receiveChat(ctx, w, m, pongs)
+	}()
+
+	for {
+		select {
+		case ev := <-m.Events():
+			if err := stream.Send(toPbEvent(ev, false)); err != nil {
+				log.Printf("Error sending chat event to %s: %v", name, err)
+				return err
+			}
+		case id := <-pongs:
+			pong := &greetpb.JoinRoomResponse_Heartbeat{Heartbeat: &greetpb.Heartbeat{Id: id, Pong: true}}
+			if err := stream.Send(&greetpb.JoinRoomResponse{Event: pong}); err != nil {
+				return err
+			}
+		case <-m.Done():
+			log.Printf("%s disconnected from room %s: %v", name, join.GetRoom(), m.Err())
+			return disconnected(m)
+		case err := <-recvErr:
+			if err == io.EOF {
+				log.Printf("%s left room %s", name, join.GetRoom())
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+}
+
+// receiveChat says the texts received by w in the room of m and passes the
+// ids of pings on to pongs until the stream ends, returning io.EOF when the
+// client closes it.
+func receiveChat(ctx context.Context, w *heartbeat.Watcher, m *chat.Member, pongs chan<- int64) error {
+	for {
+		var req *greetpb.JoinRoomRequest
+		select {
+		case msg := <-w.Messages():
+			req = msg.(*greetpb.JoinRoomRequest)
+		case err := <-w.Err():
+			return err
+		}
+
+		switch r := req.GetRequest().(type) {
+		case *greetpb.JoinRoomRequest_Join:
+			return status.Error(codes.InvalidArgument, "Already joined a room")
+		case *greetpb.JoinRoomRequest_Heartbeat:
+			if err := w.Pinged(r.Heartbeat.GetInterval()); err != nil {
+				return err
+			}
+			select {
+			case pongs <- r.Heartbeat.GetId():
+			case <-ctx.Done():
+				return status.FromContextError(ctx.Err()).Err()
+			}
+		case *greetpb.JoinRoomRequest_Text:
+			if r.Text == "" {
+				return status.Error(codes.InvalidArgument, "Empty message")
+			}
+			if len(r.Text) > maxChatText {
+				return status.Errorf(codes.InvalidArgument, "Message of %d bytes exceeds the limit of %d", len(r.Text), maxChatText)
+			}
+			if err := m.Say(r.Text); err != nil {
+				return disconnected(m)
+			}
+		default:
+			return status.Error(codes.InvalidArgument, "Empty request")
+		}
+	}
+}
+
+func disconnected(m *chat.Member) error {
+	return status.Errorf(codes.ResourceExhausted, "Disconnected from room %s: %v", m.Room, m.Err())
+}
+
+func toPbEvent(ev chat.Event, replayed bool) *greetpb.JoinRoomResponse {
+	res := &greetpb.JoinRoomResponse{
+		Sequence: ev.Seq,
+		Time:     timestamppb.New(ev.Time),
+		From:     ev.From,
+		Replayed: replayed,
+	}
+
+	switch ev.Kind {
+	case chat.Message:
+		res.Event = &greetpb.JoinRoomResponse_Text{Text: ev.Text}
+	case chat.Joined:
+		res.Event = &greetpb.JoinRoomResponse_Presence{Presence: &greetpb.Presence{
+			Kind:    greetpb.Presence_JOINED,
+			Members: ev.Members,
+		}}
+	case chat.Left:
+		res.Event = &greetpb.JoinRoomResponse_Presence{Presence: &greetpb.Presence{
+			Kind:    greetpb.Presence_LEFT,
+			Members: ev.Members,
+		}}
+	}
+	return res
+}
@@ -0,0 +1,22 @@
+package greetserver
+
+import (
+	"context"
+
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/greet/i18n"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// localizer returns the localizer of the greeting's locale or, if it has
+// none, of the Accept-Language metadata of the call.
+func localizer(ctx context.Context, g *greetpb.Greeting) (*i18n.Localizer, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	l, err := i18n.Default.Match(g.GetLocale(), md.Get("accept-language")...)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return l, nil
+}
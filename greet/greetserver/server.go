@@ -0,0 +1,287 @@
+// Package greetserver implements the GreetService.
+package greetserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/greet/chat"
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/greet/i18n"
+	"github.com/rsorage/grpc-go-course/greet/templates"
+	"github.com/rsorage/grpc-go-course/heartbeat"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements greetpb.GreetServiceServer.
+type Server struct {
+	templates       *templates.Registry
+	operatorToken   string
+	replaceTemplate bool
+	rooms           *chat.Hub
+}
+
+// Option configures New.
+type Option func(*Server)
+
+// WithTemplates serves the greeting templates of registry.
+func WithTemplates(registry *templates.Registry) Option {
+	return func(s *Server) {
+		s.templates = registry
+	}
+}
+
+// WithOperatorToken allows the callers presenting token as a bearer token
+// to create templates. Without it, CreateTemplate is disabled.
+func WithOperatorToken(token string) Option {
+	return func(s *Server) {
+		s.operatorToken = token
+	}
+}
+
+// WithTemplateReplace allows operators to replace existing templates with
+// CreateTemplate. Templates can only be added by default.
+func WithTemplateReplace(allow bool) Option {
+	return func(s *Server) {
+		s.replaceTemplate = allow
+	}
+}
+
+// WithChatHub hosts the chat rooms of JoinRoom in hub.
+func WithChatHub(hub *chat.Hub) Option {
+	return func(s *Server) {
+		s.rooms = hub
+	}
+}
+
+// New creates a GreetService implementation. Unless configured otherwise,
+// it starts with no greeting templates, does not let clients create any and
+// hosts chat rooms with the defaults of chat.NewHub.
+func New(opts ...Option) *Server {
+	s := &Server{}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.templates == nil {
+		s.templates = templates.NewRegistry()
+	}
+	if s.rooms == nil {
+		s.rooms = chat.NewHub()
+	}
+	return s
+}
+
+func (s *Server) Greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
+	fmt.Printf("Greet function was invoked with %v", req)
+	g := req.GetGreeting()
+	l, err := localizer(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	result := l.Greet(g.GetFirstName(), g.GetLastName(), g.GetFormal())
+
+	if name := req.GetTemplate(); name != "" {
+		if result, err = s.executeTemplate(name, req.GetTimeZone(), g, l, result); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &greetpb.GreetResponse{
+		Result: result,
+	}
+
+	return response, nil
+}
+
+// Limits of GreetManyTimes.
+const (
+	defaultCount    = 10
+	maxCount        = 10000
+	defaultInterval = time.Second
+	maxInterval     = time.Hour
+)
+
+func (*Server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
+	fmt.Printf("GreetManyTimes function was invoked with %v", req)
+
+	count := int(req.GetCount())
+	if count == 0 {
+		count = defaultCount
+	}
+	if count < 0 || count > maxCount {
+		return status.Errorf(codes.InvalidArgument, "Count must be between 0 and %d, got %d", maxCount, count)
+	}
+	interval := defaultInterval
+	if req.Interval != nil {
+		if err := req.Interval.CheckValid(); err != nil {
+			return status.Errorf(codes.InvalidArgument, "Invalid interval: %v", err)
+		}
+		interval = req.Interval.AsDuration()
+	}
+	if interval < 0 || interval > maxInterval {
+		return status.Errorf(codes.InvalidArgument, "Interval must be between 0 and %v, got %v", maxInterval, interval)
+	}
+	start := int(req.GetStartSequence())
+	if start < 0 || start > count {
+		return status.Errorf(codes.InvalidArgument, "Start sequence must be between 0 and %d, got %d", count, start)
+	}
+
+	ctx := stream.Context()
+	g := req.GetGreeting()
+	l, err := localizer(ctx, g)
+	if err != nil {
+		return err
+	}
+	greeting := l.Greet(g.GetFirstName(), g.GetLastName(), g.GetFormal())
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for i := start; i < count; i++ {
+		select {
+		case <-ctx.Done():
+			log.Printf("GreetManyTimes stopped at sequence %d: %v", i, ctx.Err())
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+
+		response := &greetpb.GreetManyTimesResponse{
+			Result:   l.Numbered(greeting, i),
+			Sequence: int32(i),
+		}
+		if err := stream.Send(response); err != nil {
+			log.Printf("Error sending greeting %d: %v", i, err)
+			return err
+		}
+		timer.Reset(interval)
+	}
+
+	return nil
+}
+
+func (*Server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
+	log.Println("LongGreet function was invoked with a streaming request")
+
+	// everyone is greeted in the language of the first greeting
+	var l *i18n.Localizer
+	var formal bool
+	var names []string
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			// we have finished reading the client stream
+			break
+		}
+		if err != nil {
+			log.Printf("Error while reading client stream: %v\n", err)
+			return err
+		}
+
+		g := req.GetGreeting()
+		if l == nil {
+			if l, err = localizer(stream.Context(), g); err != nil {
+				return err
+			}
+			formal = g.GetFormal()
+		}
+		names = append(names, l.Name(g.GetFirstName(), g.GetLastName(), formal))
+	}
+
+	if len(names) == 0 {
+		return status.Error(codes.InvalidArgument, "No one to greet")
+	}
+
+	return stream.SendAndClose(&greetpb.LongGreetResponse{
+		Result: l.Exclaim(l.GreetAll(names, formal)),
+	})
+}
+
+func (*Server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
+	log.Println("GreetEveryone function was invoked with a streaming request")
+
+	ctx := stream.Context()
+	w := heartbeat.Watch(ctx, func() (interface{}, error) { return stream.Recv() }, 0)
+
+	for {
+		var req *greetpb.GreetEveryoneRequest
+		select {
+		case msg := <-w.Messages():
+			req = msg.(*greetpb.GreetEveryoneRequest)
+		case err := <-w.Err():
+			if err == io.EOF {
+				log.Println("Client closed stream.")
+				return nil
+			}
+			log.Printf("Error while reading client stream: %v", err)
+			return err
+		}
+
+		if hb := req.GetHeartbeat(); hb != nil {
+			if err := w.Pinged(hb.GetInterval()); err != nil {
+				return err
+			}
+			pong := &greetpb.Heartbeat{Id: hb.GetId(), Pong: true}
+			if err := stream.Send(&greetpb.GreetEveryoneResponse{Heartbeat: pong}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		g := req.GetGreeting()
+		l, err := localizer(ctx, g)
+		if err != nil {
+			return err
+		}
+		result := l.Exclaim(l.Greet(g.GetFirstName(), g.GetLastName(), g.GetFormal()))
+
+		if err = stream.Send(&greetpb.GreetEveryoneResponse{Result: result}); err != nil {
+			log.Printf("Error while writing to client stream: %v", err)
+			return err
+		}
+
+		log.Println("Greet sent to " + g.GetFirstName())
+		if err := sleep(ctx, 800*time.Millisecond); err != nil {
+			return err
+		}
+	}
+}
+
+func (*Server) GreetWithDeadline(ctx context.Context, req *greetpb.GreetWithDeadlineRequest) (*greetpb.GreetWithDeadlineResponse, error) {
+	log.Printf("GreetWithDeadline was invoked with: %v\n", req)
+
+	// simulates 3s of work, abandoned as soon as the client cancels the
+	// call or its deadline expires
+	if err := sleep(ctx, 3*time.Second); err != nil {
+		log.Printf("GreetWithDeadline abandoned: %v", err)
+		return nil, err
+	}
+	g := req.GetGreeting()
+	l, err := localizer(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	result := l.Greet(g.GetFirstName(), g.GetLastName(), g.GetFormal())
+	res := &greetpb.GreetWithDeadlineResponse{
+		Result: result,
+	}
+	return res, nil
+}
+
+// sleep pauses for d, returning early with the status of ctx's error if it
+// is cancelled or its deadline expires.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-t.C:
+		return nil
+	}
+}
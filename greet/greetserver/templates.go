@@ -0,0 +1,102 @@
+package greetserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/greet/i18n"
+	"github.com/rsorage/grpc-go-course/greet/templates"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) CreateTemplate(ctx context.Context, req *greetpb.CreateTemplateRequest) (*greetpb.CreateTemplateResponse, error) {
+	if err := s.authorizeOperator(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetReplace() && !s.replaceTemplate {
+		return nil, status.Error(codes.PermissionDenied, "Replacing templates is disabled on this server")
+	}
+
+	tmpl := req.GetTemplate()
+	t, err := s.templates.Add(tmpl.GetName(), tmpl.GetText(), req.GetReplace())
+	if errors.Is(err, templates.ErrExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "Template %q already exists", tmpl.GetName())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &greetpb.CreateTemplateResponse{
+		Template: toPbTemplate(t),
+	}, nil
+}
+
+// authorizeOperator checks that the caller presents the operator token in
+// the authorization metadata, as "Bearer <token>".
+func (s *Server) authorizeOperator(ctx context.Context) error {
+	if s.operatorToken == "" {
+		return status.Error(codes.PermissionDenied, "Creating templates is disabled on this server")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get("authorization")
+	if len(auth) == 0 || !strings.HasPrefix(auth[0], "Bearer ") {
+		return status.Error(codes.Unauthenticated, "Creating templates requires an operator bearer token")
+	}
+	token := strings.TrimPrefix(auth[0], "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.operatorToken)) != 1 {
+		return status.Error(codes.PermissionDenied, "Invalid operator token")
+	}
+	return nil
+}
+
+func (s *Server) ListTemplates(ctx context.Context, req *greetpb.ListTemplatesRequest) (*greetpb.ListTemplatesResponse, error) {
+	res := &greetpb.ListTemplatesResponse{}
+	for _, t := range s.templates.List() {
+		res.Templates = append(res.Templates, toPbTemplate(t))
+	}
+	return res, nil
+}
+
+func toPbTemplate(t *templates.Template) *greetpb.GreetingTemplate {
+	return &greetpb.GreetingTemplate{Name: t.Name, Text: t.Text}
+}
+
+// executeTemplate greets with the named template, at the current time in
+// the given time zone.
+func (s *Server) executeTemplate(name, timeZone string, g *greetpb.Greeting, l *i18n.Localizer, greeting string) (string, error) {
+	t, ok := s.templates.Get(name)
+	if !ok {
+		return "", status.Errorf(codes.NotFound, "Template %q not found", name)
+	}
+
+	loc := time.Local
+	if timeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timeZone); err != nil {
+			return "", status.Errorf(codes.InvalidArgument, "Unknown time zone %q", timeZone)
+		}
+	}
+	now := time.Now().In(loc)
+
+	result, err := t.Execute(templates.Data{
+		FirstName: i18n.NormalizeName(g.GetFirstName()),
+		LastName:  i18n.NormalizeName(g.GetLastName()),
+		Name:      l.Name(g.GetFirstName(), g.GetLastName(), g.GetFormal()),
+		Greeting:  greeting,
+		Locale:    l.Tag.String(),
+		Formal:    g.GetFormal(),
+		Time:      now,
+		TimeOfDay: templates.TimeOfDay(now),
+	})
+	if err != nil {
+		return "", status.Errorf(codes.FailedPrecondition, "Template %q failed: %v", name, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,42 @@
+package greetserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTemplateAuthorization(t *testing.T) {
+	withToken := func(token string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	}
+	tmpl := &greetpb.GreetingTemplate{Name: "hi", Text: "Hi {{.Name}}"}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		ctx     context.Context
+		replace bool
+		want    codes.Code
+	}{
+		{"disabled", nil, withToken("s3cret"), false, codes.PermissionDenied},
+		{"no token", []Option{WithOperatorToken("s3cret")}, context.Background(), false, codes.Unauthenticated},
+		{"wrong token", []Option{WithOperatorToken("s3cret")}, withToken("guess"), false, codes.PermissionDenied},
+		{"operator", []Option{WithOperatorToken("s3cret")}, withToken("s3cret"), false, codes.OK},
+		{"replace refused", []Option{WithOperatorToken("s3cret")}, withToken("s3cret"), true, codes.PermissionDenied},
+		{"replace allowed", []Option{WithOperatorToken("s3cret"), WithTemplateReplace(true)}, withToken("s3cret"), true, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(tt.opts...)
+			_, err := s.CreateTemplate(tt.ctx, &greetpb.CreateTemplateRequest{Template: tmpl, Replace: tt.replace})
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("CreateTemplate() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
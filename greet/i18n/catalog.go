@@ -0,0 +1,78 @@
+package i18n
+
+import "golang.org/x/text/language"
+
+// Default is the catalog of the built-in languages, English being the
+// fallback.
+var Default = func() *Catalog {
+	tags := make([]language.Tag, len(builtin))
+	messages := make([]Messages, len(builtin))
+	for i, b := range builtin {
+		tags[i], messages[i] = b.tag, b.messages
+	}
+	c, err := NewCatalog(tags, messages)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+var builtin = []struct {
+	tag      language.Tag
+	messages Messages
+}{
+	{language.English, Messages{
+		Informal:   "Hello, %s",
+		Formal:     "Good day, %s",
+		FormalName: "%[1]s %[2]s",
+		Numbered:   "%[1]s number %[2]d",
+		Exclaim:    "%s!",
+		ListSep:    ", ",
+		ListLast:   " and ",
+	}},
+	{language.German, Messages{
+		Informal:   "Hallo, %s",
+		Formal:     "Guten Tag, %s",
+		FormalName: "%[1]s %[2]s",
+		Numbered:   "%[1]s Nummer %[2]d",
+		Exclaim:    "%s!",
+		ListSep:    ", ",
+		ListLast:   " und ",
+	}},
+	{language.French, Messages{
+		Informal:   "Salut, %s",
+		Formal:     "Bonjour, %s",
+		FormalName: "%[1]s %[2]s",
+		Numbered:   "%[1]s numéro %[2]d",
+		Exclaim:    "%s\u202f!",
+		ListSep:    ", ",
+		ListLast:   " et ",
+	}},
+	{language.Spanish, Messages{
+		Informal:   "Hola, %s",
+		Formal:     "Buenos días, %s",
+		FormalName: "%[1]s %[2]s",
+		Numbered:   "%[1]s número %[2]d",
+		Exclaim:    "¡%s!",
+		ListSep:    ", ",
+		ListLast:   " y ",
+	}},
+	{language.Portuguese, Messages{
+		Informal:   "Olá, %s",
+		Formal:     "Bom dia, %s",
+		FormalName: "%[1]s %[2]s",
+		Numbered:   "%[1]s número %[2]d",
+		Exclaim:    "%s!",
+		ListSep:    ", ",
+		ListLast:   " e ",
+	}},
+	{language.Japanese, Messages{
+		Informal:   "こんにちは、%sさん",
+		Formal:     "こんにちは、%s様",
+		FormalName: "%[2]s",
+		Numbered:   "%[1]s（%[2]d回目）",
+		Exclaim:    "%s！",
+		ListSep:    "、",
+		ListLast:   "、",
+	}},
+}
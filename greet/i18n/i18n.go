@@ -0,0 +1,203 @@
+// Package i18n localizes greetings.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Messages are the formats of the greetings of a language.
+type Messages struct {
+	// Informal greets a person by the first name, e.g. "Hello, %s".
+	Informal string
+	// Formal greets a person by the name formatted by FormalName, e.g.
+	// "Good day, %s".
+	Formal string
+	// FormalName formats the first (%[1]s) and last (%[2]s) names in the
+	// order and with the honorifics of the language, e.g. "%[1]s %[2]s".
+	FormalName string
+	// Numbered numbers a greeting (%[1]s) with %[2]d, e.g.
+	// "%[1]s number %[2]d".
+	Numbered string
+	// Exclaim turns a greeting into an exclamation, e.g. "%s!".
+	Exclaim string
+	// ListSep and ListLast separate the items of a list, e.g. ", " and
+	// " and ".
+	ListSep, ListLast string
+}
+
+// Catalog holds the messages of several languages.
+type Catalog struct {
+	tags     []language.Tag
+	messages []Messages
+	matcher  language.Matcher
+}
+
+// NewCatalog creates a catalog. The first language is the fallback used
+// when no other matches.
+func NewCatalog(tags []language.Tag, messages []Messages) (*Catalog, error) {
+	if len(tags) == 0 || len(tags) != len(messages) {
+		return nil, fmt.Errorf("i18n: need as many messages as languages, and at least one")
+	}
+	return &Catalog{
+		tags:     tags,
+		messages: messages,
+		matcher:  language.NewMatcher(tags),
+	}, nil
+}
+
+// Languages returns the languages of the catalog.
+func (c *Catalog) Languages() []language.Tag {
+	return append([]language.Tag(nil), c.tags...)
+}
+
+// InvalidLocaleError is returned by Match for locales that are not
+// well-formed BCP 47 language tags.
+type InvalidLocaleError struct {
+	Locale string
+}
+
+func (e *InvalidLocaleError) Error() string {
+	return fmt.Sprintf("invalid locale %q", e.Locale)
+}
+
+// Match returns the localizer of the language best matching locale or, if
+// it is empty, the Accept-Language header values. Malformed or unknown
+// languages of the header are ignored.
+func (c *Catalog) Match(locale string, acceptLanguage ...string) (*Localizer, error) {
+	var prefs []language.Tag
+	if locale != "" {
+		// well-formed tags of unknown languages fall back like any other
+		// unsupported language
+		tag, err := language.Parse(locale)
+		if _, unknown := err.(language.ValueError); err != nil && !unknown {
+			return nil, &InvalidLocaleError{Locale: locale}
+		}
+		prefs = []language.Tag{tag}
+	} else {
+		prefs = parseAcceptLanguage(acceptLanguage)
+	}
+
+	_, i, conf := c.matcher.Match(prefs...)
+	if conf == language.No {
+		i = 0
+	}
+	return &Localizer{Tag: c.tags[i], m: c.messages[i]}, nil
+}
+
+// parseAcceptLanguage returns the languages of Accept-Language header
+// values by decreasing preference. Unlike language.ParseAcceptLanguage, a
+// single bad entry does not invalidate the whole header.
+func parseAcceptLanguage(headers []string) []language.Tag {
+	type pref struct {
+		tag language.Tag
+		q   float32
+	}
+	var prefs []pref
+	for _, h := range headers {
+		for _, entry := range strings.Split(h, ",") {
+			tags, q, err := language.ParseAcceptLanguage(entry)
+			if err != nil || len(tags) == 0 {
+				continue
+			}
+			prefs = append(prefs, pref{tags[0], q[0]})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+	tags := make([]language.Tag, len(prefs))
+	for i, p := range prefs {
+		tags[i] = p.tag
+	}
+	return tags
+}
+
+// Localizer formats greetings in one language.
+type Localizer struct {
+	Tag language.Tag
+	m   Messages
+}
+
+// Name returns the name a person is greeted by: the first name, or the
+// full name when formal. A missing name is replaced by the other one.
+func (l *Localizer) Name(firstName, lastName string, formal bool) string {
+	first, last := NormalizeName(firstName), NormalizeName(lastName)
+	switch {
+	case first == "":
+		return last
+	case !formal || last == "":
+		return first
+	}
+	return fmt.Sprintf(l.m.FormalName, first, last)
+}
+
+// Greet greets a person, informally by the first name or formally by the
+// full name.
+func (l *Localizer) Greet(firstName, lastName string, formal bool) string {
+	return l.greet(l.Name(firstName, lastName, formal), formal)
+}
+
+// GreetAll greets several people at once by the names returned by Name.
+func (l *Localizer) GreetAll(names []string, formal bool) string {
+	return l.greet(l.List(names), formal)
+}
+
+func (l *Localizer) greet(name string, formal bool) string {
+	if formal {
+		return fmt.Sprintf(l.m.Formal, name)
+	}
+	return fmt.Sprintf(l.m.Informal, name)
+}
+
+// Numbered numbers a greeting.
+func (l *Localizer) Numbered(greeting string, n int) string {
+	return fmt.Sprintf(l.m.Numbered, greeting, n)
+}
+
+// Exclaim turns a greeting into an exclamation.
+func (l *Localizer) Exclaim(greeting string) string {
+	return fmt.Sprintf(l.m.Exclaim, greeting)
+}
+
+// List joins items the way the language lists them, e.g. "a, b and c".
+func (l *Localizer) List(items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	return strings.Join(items[:len(items)-1], l.m.ListSep) + l.m.ListLast + items[len(items)-1]
+}
+
+// NormalizeName prepares a name for display: it is converted to Unicode
+// normalization form C so that precomposed and combining accents compare
+// and render alike, control and formatting characters are removed and runs
+// of white space are collapsed. The case is kept as is, since names like
+// "van der Berg" or "McDonald" cannot be case mapped reliably.
+func NormalizeName(name string) string {
+	name = norm.NFC.String(name)
+
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
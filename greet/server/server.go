@@ -1,143 +1,55 @@
 package main
 
 import (
-	"context"
-	"fmt"
-	"io"
+	"flag"
+	"io/ioutil"
 	"log"
-	"net"
-	"strconv"
-	"time"
+	"strings"
 
+	"github.com/rsorage/grpc-go-course/greet/chat"
 	"github.com/rsorage/grpc-go-course/greet/greetpb"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/status"
+	"github.com/rsorage/grpc-go-course/greet/greetserver"
+	"github.com/rsorage/grpc-go-course/greet/templates"
+	"github.com/rsorage/grpc-go-course/grpcserver"
 )
 
-type server struct{}
-
-func (*server) Greet(ctx context.Context, req *greetpb.GreetRequest) (*greetpb.GreetResponse, error) {
-	fmt.Printf("Greet function was invoked with %v", req)
-	firstName := req.GetGreeting().GetFirstName()
-	result := "Hello, " + firstName
-
-	response := &greetpb.GreetResponse{
-		Result: result,
-	}
-
-	return response, nil
-}
-
-func (*server) GreetManyTimes(req *greetpb.GreetManyTimesRequest, stream greetpb.GreetService_GreetManyTimesServer) error {
-	fmt.Printf("GreetManyTimes function was invoked with %v", req)
-	firstName := req.GetGreeting().GetFirstName()
-
-	for i := 0; i < 10; i++ {
-		result := "Hello, " + firstName + " number " + strconv.Itoa(i)
-		response := &greetpb.GreetManyTimesResponse{
-			Result: result,
-		}
-
-		time.Sleep(1 * time.Second)
-		stream.Send(response)
-	}
-
-	return nil
-}
-
-func (*server) LongGreet(stream greetpb.GreetService_LongGreetServer) error {
-	log.Println("LongGreet function was invoked with a streaming request")
-	result := "Hello "
-
-	for {
-		req, err := stream.Recv()
-		if err == io.EOF {
-			// we have finished reading the client stream
-			return stream.SendAndClose(&greetpb.LongGreetResponse{
-				Result: result,
-			})
-		}
-		if err != nil {
-			log.Fatalf("Error while reading client stream: %v\n", err)
-			return err
-		}
-
-		firstName := req.GetGreeting().GetFirstName()
-		result += firstName + "! "
-	}
-}
-
-func (*server) GreetEveryone(stream greetpb.GreetService_GreetEveryoneServer) error {
-	log.Println("GreetEveryone function was invoked with a streaming request")
-
-	for {
-		req, err := stream.Recv()
-		if err == io.EOF {
-			log.Println("Client closed stream.")
-			return nil
-		}
+func main() {
+	cfg := grpcserver.DefaultConfig("0.0.0.0:50051")
+	cfg.RegisterFlags(flag.CommandLine)
+	templatesDir := flag.String("templates", "", "directory of *.tmpl greeting templates to load at startup")
+	tokenFile := flag.String("template-token-file", "", "file holding the operator token allowing clients to create templates (disabled if empty)")
+	allowReplace := flag.Bool("template-replace", false, "allow operators to replace existing templates")
+	chatHistory := flag.Int("chat-history", chat.DefaultHistory, "chat events replayed to members joining a room")
+	chatBuffer := flag.Int("chat-buffer", chat.DefaultBuffer, "chat events a member may fall behind before being disconnected")
+	flag.Parse()
+
+	registry := templates.NewRegistry()
+	if *templatesDir != "" {
+		if err := registry.LoadDir(*templatesDir); err != nil {
+			log.Fatalf("Failed to load templates: %v", err)
+		}
+	}
+	var operatorToken string
+	if *tokenFile != "" {
+		b, err := ioutil.ReadFile(*tokenFile)
 		if err != nil {
-			log.Fatalf("Error while reading client stream: %v", err)
-			return err
-		}
-
-		firstName := req.GetGreeting().GetFirstName()
-		result := "Hello, " + firstName + "!"
-
-		if err = stream.Send(&greetpb.GreetEveryoneResponse{Result: result}); err != nil {
-			log.Fatalf("Error while writíng to client stream: %v", err)
-			return err
-		}
-
-		time.Sleep(800 * time.Millisecond)
-		log.Println("Greet sent to " + firstName)
-	}
-}
-
-func (*server) GreetWithDeadline(ctx context.Context, req *greetpb.GreetWithDeadlineRequest) (*greetpb.GreetWithDeadlineResponse, error) {
-	log.Printf("GreetWithDeadline was invoked with: %v\n", req)
-
-	for i := 0; i < 3; i++ {
-		if ctx.Err() == context.Canceled {
-			log.Println("The client canceled the request.")
-			return nil, status.Error(codes.Canceled, "The client canceled the request.")
+			log.Fatalf("Failed to read the template token: %v", err)
 		}
-		time.Sleep(1 * time.Second)
-	}
-	firstName := req.GetGreeting().GetFirstName()
-	result := "Hello, " + firstName
-	res := &greetpb.GreetWithDeadlineResponse{
-		Result: result,
+		operatorToken = strings.TrimSpace(string(b))
 	}
-	return res, nil
-}
 
-func main() {
-	lis, err := net.Listen("tcp", "0.0.0.0:50051")
+	s, err := grpcserver.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		log.Fatalf("Failed to create server: %v", err)
 	}
+	greetpb.RegisterGreetServiceServer(s.Server, greetserver.New(
+		greetserver.WithTemplates(registry),
+		greetserver.WithOperatorToken(operatorToken),
+		greetserver.WithTemplateReplace(*allowReplace),
+		greetserver.WithChatHub(chat.NewHub(chat.WithHistory(*chatHistory), chat.WithBuffer(*chatBuffer))),
+	))
 
-	opts := []grpc.ServerOption{}
-	tls := false
-	if tls {
-		certFile := "ssl/server.crt"
-		keyFile := "ssl/server.pem"
-		creds, sslErr := credentials.NewServerTLSFromFile(certFile, keyFile)
-		if sslErr != nil {
-			log.Fatalf("Failed loading certificates: %v", sslErr)
-			return
-		}
-
-		opts = append(opts, grpc.Creds(creds))
-	}
-
-	s := grpc.NewServer(opts...)
-	greetpb.RegisterGreetServiceServer(s, &server{})
-
-	if err := s.Serve(lis); err != nil {
+	if err := s.ListenAndServe(); err != nil {
 		log.Fatalf("Failed to serve: %v", err)
 	}
 }
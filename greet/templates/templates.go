@@ -0,0 +1,301 @@
+// Package templates keeps the named greeting templates registered by
+// operators. Templates use the text/template syntax and are executed with
+// Data. They may not loop or invoke other templates, so that they run in
+// time proportional to their size.
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode"
+)
+
+// MaxSize is the maximum size in bytes of a template and of its output.
+const MaxSize = 16 << 10
+
+// Data is what a template is executed with.
+type Data struct {
+	FirstName string
+	LastName  string
+	// Name is the localized name the person is greeted by.
+	Name string
+	// Greeting is the localized greeting GreetService returns when no
+	// template is selected.
+	Greeting string
+	// Locale is the language tag the greeting was localized in.
+	Locale string
+	Formal bool
+	// Time is the time of the greeting, in the time zone of the request.
+	Time time.Time
+	// TimeOfDay is "morning", "afternoon", "evening" or "night".
+	TimeOfDay string
+}
+
+// TimeOfDay names the part of the day t falls in: morning from 5:00,
+// afternoon from 12:00, evening from 18:00 and night from 22:00.
+func TimeOfDay(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 5 && h < 12:
+		return "morning"
+	case h >= 12 && h < 18:
+		return "afternoon"
+	case h >= 18 && h < 22:
+		return "evening"
+	}
+	return "night"
+}
+
+// ErrExists is returned when adding a template whose name is taken.
+var ErrExists = errors.New("template already exists")
+
+// InvalidError is returned for a template that cannot be parsed or
+// executed, or whose name is not valid.
+type InvalidError struct {
+	Name string
+	Err  error
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("invalid template %q: %v", e.Name, e.Err)
+}
+
+func (e *InvalidError) Unwrap() error {
+	return e.Err
+}
+
+// Template is a parsed greeting template.
+type Template struct {
+	Name string
+	Text string
+	t    *template.Template
+}
+
+// Execute renders the template.
+func (t *Template) Execute(data Data) (string, error) {
+	w := &limitedBuffer{max: MaxSize}
+	if err := t.t.Execute(w, data); err != nil {
+		return "", err
+	}
+	return w.String(), nil
+}
+
+// limitedBuffer fails writes past max bytes, so that a template cannot
+// produce an unbounded greeting.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		return 0, fmt.Errorf("output exceeds %d bytes", b.max)
+	}
+	return b.Buffer.Write(p)
+}
+
+// sample is used to check new templates for execution errors, such as
+// references to fields Data does not have.
+var sample = Data{
+	FirstName: "Ada",
+	LastName:  "Lovelace",
+	Name:      "Ada",
+	Greeting:  "Hello, Ada",
+	Locale:    "en",
+	Time:      time.Date(1843, 7, 10, 9, 0, 0, 0, time.UTC),
+	TimeOfDay: "morning",
+}
+
+// Parse parses and checks a template.
+func Parse(name, text string) (*Template, error) {
+	if !validName(name) {
+		return nil, &InvalidError{name, errors.New("names are made of letters, digits, '-', '_' and '.'")}
+	}
+	if len(text) > MaxSize {
+		return nil, &InvalidError{name, fmt.Errorf("larger than %d bytes", MaxSize)}
+	}
+
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, &InvalidError{name, err}
+	}
+	if err := checkTree(t); err != nil {
+		return nil, &InvalidError{name, err}
+	}
+	tmpl := &Template{Name: name, Text: text, t: t}
+	if _, err := tmpl.Execute(sample); err != nil {
+		return nil, &InvalidError{name, err}
+	}
+	return tmpl, nil
+}
+
+// funcs are the template functions that run in time proportional to their
+// arguments, whose sizes are bounded by Data and MaxSize.
+var funcs = map[string]bool{
+	"and": true, "or": true, "not": true,
+	"eq": true, "ne": true, "lt": true, "le": true, "gt": true, "ge": true,
+	"len": true, "index": true, "slice": true,
+	"print": true, "printf": true, "println": true,
+	"html": true, "js": true, "urlquery": true,
+}
+
+// checkTree rejects the actions that could make a template run for long:
+// range loops, template and block invocations and definitions.
+func checkTree(t *template.Template) error {
+	if len(t.Templates()) > 1 {
+		return errors.New("define and block actions are not allowed")
+	}
+	return checkNode(t.Tree.Root)
+}
+
+func checkNode(node parse.Node) error {
+	switch n := node.(type) {
+	case nil, *parse.TextNode, *parse.CommentNode, *parse.DotNode, *parse.NilNode,
+		*parse.BoolNode, *parse.NumberNode, *parse.StringNode,
+		*parse.FieldNode, *parse.VariableNode:
+		return nil
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, c := range n.Nodes {
+			if err := checkNode(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.ActionNode:
+		return checkNode(n.Pipe)
+	case *parse.IfNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		return checkBranch(&n.BranchNode)
+	case *parse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, cmd := range n.Cmds {
+			if err := checkNode(cmd); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if err := checkNode(arg); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *parse.ChainNode:
+		return checkNode(n.Node)
+	case *parse.IdentifierNode:
+		if !funcs[n.Ident] {
+			return fmt.Errorf("function %q is not allowed", n.Ident)
+		}
+		return nil
+	case *parse.RangeNode:
+		return errors.New("range actions are not allowed")
+	case *parse.TemplateNode:
+		return errors.New("template and block actions are not allowed")
+	}
+	return fmt.Errorf("%q is not allowed", node)
+}
+
+func checkBranch(b *parse.BranchNode) error {
+	for _, n := range []parse.Node{b.Pipe, b.List, b.ElseList} {
+		if err := checkNode(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validName(name string) bool {
+	if name == "" || len(name) > 128 {
+		return false
+	}
+	for _, r := range name {
+		if r != '-' && r != '_' && r != '.' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Registry is a set of templates safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]*Template
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{templates: make(map[string]*Template)}
+}
+
+// Add parses a template and adds it to the registry. Unless replace is
+// set, it fails with ErrExists if the name is taken.
+func (r *Registry) Add(name, text string, replace bool) (*Template, error) {
+	t, err := Parse(name, text)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.templates[name]; ok && !replace {
+		return nil, ErrExists
+	}
+	r.templates[name] = t
+	return t, nil
+}
+
+// Get returns the template registered under name.
+func (r *Registry) Get(name string) (*Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.templates[name]
+	return t, ok
+}
+
+// List returns the templates sorted by name.
+func (r *Registry) List() []*Template {
+	r.mu.RLock()
+	list := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		list = append(list, t)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LoadDir adds the *.tmpl files of dir, named after the file without the
+// extension, replacing templates of the same name.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		text, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		if _, err := r.Add(name, string(text), true); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package templates
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		ok   bool
+	}{
+		{"plain", "Hi {{.Name}}", true},
+		{"if", "{{if .Formal}}Dear {{.Name}}{{else}}Hey {{.FirstName}}{{end}}", true},
+		{"with", "{{with .LastName}}{{.}}{{end}}", true},
+		{"funcs", `{{printf "%s!" .Greeting | html}} {{len .Name}} {{if eq .TimeOfDay "morning"}}gm{{end}}`, true},
+		{"time method", `{{.Time.Format "15:04"}}`, true},
+		{"range", "{{range 300000000}}{{end}}hi", false},
+		{"range over field", "{{range .Name}}{{end}}", false},
+		{"range in if", "{{if .Formal}}{{range .Name}}{{end}}{{end}}", false},
+		{"define", `{{define "x"}}a{{end}}b`, false},
+		{"template", `{{template "x"}}`, false},
+		{"block", `{{block "x" .}}a{{end}}`, false},
+		{"call", "{{call .Name}}", false},
+		{"unknown field", "{{.Nickname}}", false},
+		{"syntax", "{{.Name", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := Parse("t", tt.text)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Parse took %v", elapsed)
+			}
+			if tt.ok && err != nil {
+				t.Errorf("Parse(%q) = %v, want nil", tt.text, err)
+			}
+			if !tt.ok {
+				var invalid *InvalidError
+				if !errors.As(err, &invalid) {
+					t.Errorf("Parse(%q) = %v, want an *InvalidError", tt.text, err)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteOutputLimit(t *testing.T) {
+	if _, err := Parse("big", `{{printf "%0999999d" 1}}`); err == nil {
+		t.Fatalf("Parse accepted a template whose output exceeds %d bytes", MaxSize)
+	}
+}
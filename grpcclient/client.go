@@ -0,0 +1,241 @@
+// Package grpcclient builds client connections configured with the call
+// policies of the course services: default deadlines, retries with
+// exponential backoff, hedging of idempotent reads and compression.
+package grpcclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/compression"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultUnaryTimeout is the deadline given to unary calls that have none.
+const DefaultUnaryTimeout = 10 * time.Second
+
+// RequestIDMetadataKey is the request metadata identifying a unary call.
+// Every attempt of a call, retried or hedged, carries the same ID, which
+// lets servers apply the effects of a call only once.
+const RequestIDMetadataKey = "x-request-id"
+
+// DefaultKeepalive pings the server every 30 seconds while streams are open,
+// which keeps long-lived streams alive through proxies that drop idle
+// connections. Servers must allow pings at least this often, see
+// grpcserver.Config.KeepaliveMinTime.
+var DefaultKeepalive = keepalive.ClientParameters{
+	Time:    30 * time.Second,
+	Timeout: 10 * time.Second,
+}
+
+type options struct {
+	tls          bool
+	caFile       string
+	policies     []MethodPolicy
+	unaryTimeout time.Duration
+	keepalive    keepalive.ClientParameters
+	compressor   string
+	methodComp   map[string]string
+	dialOpts     []grpc.DialOption
+}
+
+// Option configures Dial.
+type Option func(*options)
+
+// WithTLS enables transport security, trusting the CA certificate in
+// caFile. An empty caFile uses the system roots.
+func WithTLS(caFile string) Option {
+	return func(o *options) {
+		o.tls = true
+		o.caFile = caFile
+	}
+}
+
+// WithPolicies replaces DefaultPolicies.
+func WithPolicies(policies ...MethodPolicy) Option {
+	return func(o *options) {
+		o.policies = policies
+	}
+}
+
+// WithUnaryTimeout sets the deadline of unary calls made without one,
+// replacing DefaultUnaryTimeout. Zero disables it.
+func WithUnaryTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.unaryTimeout = d
+	}
+}
+
+// WithKeepalive pings the server after the connection has been idle for
+// interval, closing it if the server does not answer within timeout. It
+// replaces DefaultKeepalive; a zero interval disables keepalive pings.
+func WithKeepalive(interval, timeout time.Duration, permitWithoutStream bool) Option {
+	return func(o *options) {
+		o.keepalive = keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+	}
+}
+
+// WithCompression compresses the messages of every call with the named
+// compressor, see package compression. Servers answer with the same one.
+// compression.Identity disables compression, which is the default.
+func WithCompression(name string) Option {
+	return func(o *options) {
+		o.compressor = name
+	}
+}
+
+// WithMethodCompression compresses the messages of calls to the full
+// method, e.g. "/blog.BlogService/ListBlog", with the named compressor,
+// overriding WithCompression. A grpc.UseCompressor call option overrides
+// both for a single call.
+func WithMethodCompression(method, name string) Option {
+	return func(o *options) {
+		if o.methodComp == nil {
+			o.methodComp = make(map[string]string)
+		}
+		o.methodComp[method] = name
+	}
+}
+
+// WithDialOptions appends raw gRPC dial options.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// Dial connects to target. Unless configured otherwise, the connection is
+// insecure and uses DefaultPolicies and DefaultKeepalive.
+func Dial(target string, opts ...Option) (*grpc.ClientConn, error) {
+	o := &options{
+		policies:     DefaultPolicies,
+		unaryTimeout: DefaultUnaryTimeout,
+		keepalive:    DefaultKeepalive,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	transport := grpc.WithInsecure()
+	if o.tls {
+		creds := credentials.NewClientTLSFromCert(nil, "")
+		if o.caFile != "" {
+			var err error
+			if creds, err = credentials.NewClientTLSFromFile(o.caFile, ""); err != nil {
+				return nil, fmt.Errorf("loading CA trust certificate: %v", err)
+			}
+		}
+		transport = grpc.WithTransportCredentials(creds)
+	}
+
+	if o.compressor != "" {
+		if err := compression.Check(o.compressor); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range o.methodComp {
+		if err := compression.Check(name); err != nil {
+			return nil, err
+		}
+	}
+
+	sc, err := serviceConfig(o.policies)
+	if err != nil {
+		return nil, err
+	}
+
+	hedged := make(map[string]*HedgingPolicy)
+	for _, p := range o.policies {
+		if p.Hedging != nil && p.Method != "" {
+			hedged[p.fullMethod()] = p.Hedging
+		}
+	}
+
+	dialOpts := []grpc.DialOption{
+		transport,
+		grpc.WithDefaultServiceConfig(sc),
+		grpc.WithChainUnaryInterceptor(
+			unaryCompressionInterceptor(o.compressor, o.methodComp),
+			timeoutInterceptor(o.unaryTimeout),
+			requestIDInterceptor,
+			hedgingInterceptor(hedged),
+		),
+		grpc.WithChainStreamInterceptor(
+			streamCompressionInterceptor(o.compressor, o.methodComp),
+		),
+	}
+	if o.keepalive.Time > 0 {
+		dialOpts = append(dialOpts, grpc.WithKeepaliveParams(o.keepalive))
+	}
+	dialOpts = append(dialOpts, o.dialOpts...)
+
+	return grpc.Dial(target, dialOpts...)
+}
+
+// timeoutInterceptor gives unary calls without a deadline the timeout d.
+func timeoutInterceptor(d time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// requestIDInterceptor gives unary calls without a request ID a random one.
+func requestIDInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(RequestIDMetadataKey)) == 0 {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadataKey, hex.EncodeToString(b))
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// compressorOption returns the call option selecting the compressor of
+// method, or nil if none is configured.
+func compressorOption(compressor string, methods map[string]string, method string) grpc.CallOption {
+	if name, ok := methods[method]; ok {
+		compressor = name
+	}
+	if compressor == "" {
+		return nil
+	}
+	return grpc.UseCompressor(compressor)
+}
+
+// unaryCompressionInterceptor applies the connection and per method
+// compressors. Call options given by the caller come last and take
+// precedence.
+func unaryCompressionInterceptor(compressor string, methods map[string]string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if opt := compressorOption(compressor, methods, method); opt != nil {
+			opts = append([]grpc.CallOption{opt}, opts...)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func streamCompressionInterceptor(compressor string, methods map[string]string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if opt := compressorOption(compressor, methods, method); opt != nil {
+			opts = append([]grpc.CallOption{opt}, opts...)
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
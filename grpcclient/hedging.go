@@ -0,0 +1,110 @@
+package grpcclient
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type hedgedResult struct {
+	reply interface{}
+	err   error
+}
+
+// hedgingInterceptor sends additional attempts of the hedged unary methods
+// if the previous ones have not completed within the hedging delay. The
+// first successful attempt wins and the others are cancelled.
+func hedgingInterceptor(policies map[string]*HedgingPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p, ok := policies[method]
+		if !ok || p.MaxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		results := make(chan hedgedResult, p.MaxAttempts)
+		attempt := func() {
+			// every attempt decodes into its own reply to avoid races
+			r := newReply(reply)
+			err := invoker(ctx, method, req, r, cc, opts...)
+			results <- hedgedResult{reply: r, err: err}
+		}
+
+		go attempt()
+		sent, pending := 1, 1
+
+		timer := time.NewTimer(p.HedgingDelay)
+		defer timer.Stop()
+
+		// hedge sends the next attempt and restarts the hedging delay
+		hedge := func() {
+			go attempt()
+			sent++
+			pending++
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(p.HedgingDelay)
+		}
+
+		var lastErr error
+		for pending > 0 {
+			select {
+			case <-timer.C:
+				if sent < p.MaxAttempts {
+					hedge()
+				}
+			case res := <-results:
+				pending--
+				if res.err == nil {
+					copyReply(reply, res.reply)
+					return nil
+				}
+				lastErr = res.err
+				if !isNonFatal(p, res.err) {
+					return res.err
+				}
+				// a non-fatal failure triggers the next attempt right away
+				if sent < p.MaxAttempts {
+					hedge()
+				}
+			}
+		}
+
+		return lastErr
+	}
+}
+
+func isNonFatal(p *HedgingPolicy, err error) bool {
+	code := status.Code(err)
+	for _, c := range p.NonFatalCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func newReply(reply interface{}) interface{} {
+	if m, ok := reply.(proto.Message); ok {
+		return m.ProtoReflect().New().Interface()
+	}
+	return reply
+}
+
+func copyReply(dst, src interface{}) {
+	if dst == src {
+		return
+	}
+	d, s := dst.(proto.Message), src.(proto.Message)
+	proto.Reset(d)
+	proto.Merge(d, s)
+}
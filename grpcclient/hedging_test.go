@@ -0,0 +1,159 @@
+package grpcclient
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const hedgedMethod = "/test.Service/Get"
+
+// fakeAttempts answers the attempts of a hedged call with the results of
+// respond, given the attempt number from 0, and records when they start.
+type fakeAttempts struct {
+	start   time.Time
+	respond func(ctx context.Context, n int) (string, error)
+
+	mu     sync.Mutex
+	starts []time.Duration
+}
+
+func (f *fakeAttempts) invoke(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+	f.mu.Lock()
+	n := len(f.starts)
+	f.starts = append(f.starts, time.Since(f.start))
+	f.mu.Unlock()
+
+	v, err := f.respond(ctx, n)
+	if err == nil {
+		reply.(*wrapperspb.StringValue).Value = v
+	}
+	return err
+}
+
+func (f *fakeAttempts) attempts() []time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Duration(nil), f.starts...)
+}
+
+func TestHedgingInterceptor(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	policy := &HedgingPolicy{MaxAttempts: 3, HedgingDelay: delay, NonFatalCodes: []codes.Code{codes.Unavailable}}
+	interceptor := hedgingInterceptor(map[string]*HedgingPolicy{hedgedMethod: policy})
+
+	wait := func(ctx context.Context, d time.Duration) error {
+		select {
+		case <-time.After(d):
+			return nil
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	tests := []struct {
+		name    string
+		respond func(ctx context.Context, n int) (string, error)
+		want    string
+		code    codes.Code
+		// starts are the earliest start of each attempt, in delays
+		starts []int
+	}{
+		{"first succeeds", func(ctx context.Context, n int) (string, error) {
+			return "a", nil
+		}, "a", codes.OK, []int{0}},
+		{"hedged attempt wins", func(ctx context.Context, n int) (string, error) {
+			if n == 0 {
+				return "", wait(ctx, time.Hour)
+			}
+			return "b", nil
+		}, "b", codes.OK, []int{0, 1}},
+		{"non-fatal failure hedges at once and restarts the delay", func(ctx context.Context, n int) (string, error) {
+			switch n {
+			case 0:
+				return "", unavailable
+			case 1:
+				return "", wait(ctx, time.Hour)
+			}
+			return "c", nil
+		}, "c", codes.OK, []int{0, 0, 1}},
+		{"fatal failure", func(ctx context.Context, n int) (string, error) {
+			return "", status.Error(codes.InvalidArgument, "bad")
+		}, "", codes.InvalidArgument, []int{0}},
+		{"all attempts fail", func(ctx context.Context, n int) (string, error) {
+			return "", unavailable
+		}, "", codes.Unavailable, []int{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAttempts{start: time.Now(), respond: tt.respond}
+			reply := &wrapperspb.StringValue{}
+			err := interceptor(context.Background(), hedgedMethod, &wrapperspb.StringValue{}, reply, nil, f.invoke)
+			if status.Code(err) != tt.code || reply.GetValue() != tt.want {
+				t.Fatalf("call = %q, %v, want %q, %v", reply.GetValue(), err, tt.want, tt.code)
+			}
+
+			starts := f.attempts()
+			if len(starts) != len(tt.starts) {
+				t.Fatalf("%d attempts started at %v, want %d", len(starts), starts, len(tt.starts))
+			}
+			for i, s := range starts {
+				if min := time.Duration(tt.starts[i]) * delay; s < min || s > min+delay/2 {
+					t.Errorf("attempt %d started after %v, want about %v", i, s, min)
+				}
+			}
+		})
+	}
+}
+
+func TestHedgedAttemptsShareRequestID(t *testing.T) {
+	policy := &HedgingPolicy{MaxAttempts: 3, HedgingDelay: time.Hour, NonFatalCodes: []codes.Code{codes.Unavailable}}
+	hedging := hedgingInterceptor(map[string]*HedgingPolicy{hedgedMethod: policy})
+
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+	invoke := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		ids = append(ids, md.Get(RequestIDMetadataKey)...)
+		return status.Error(codes.Unavailable, "down")
+	}
+	call := func(ctx context.Context) {
+		err := requestIDInterceptor(ctx, hedgedMethod, &wrapperspb.StringValue{}, &wrapperspb.StringValue{}, nil,
+			func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				return hedging(ctx, method, req, reply, cc, invoke, opts...)
+			})
+		if status.Code(err) != codes.Unavailable {
+			t.Fatalf("call = %v, want %v", err, codes.Unavailable)
+		}
+	}
+
+	call(context.Background())
+	if len(ids) != policy.MaxAttempts || ids[0] == "" || ids[1] != ids[0] || ids[2] != ids[0] {
+		t.Errorf("attempts sent request IDs %q, want %d equal ones", ids, policy.MaxAttempts)
+	}
+	first := ids[0]
+
+	ids = nil
+	call(context.Background())
+	if len(ids) == 0 || ids[0] == first {
+		t.Errorf("second call sent request IDs %q, want a new one", ids)
+	}
+
+	ids = nil
+	call(metadata.AppendToOutgoingContext(context.Background(), RequestIDMetadataKey, "given"))
+	if len(ids) != policy.MaxAttempts || ids[0] != "given" {
+		t.Errorf("call with a request ID sent %q, want it kept", ids)
+	}
+}
@@ -0,0 +1,183 @@
+package grpcclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// RetryPolicy configures how failed attempts of an RPC are retried with
+// exponential backoff.
+type RetryPolicy struct {
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	BackoffMultiplier float64
+	RetryableCodes    []codes.Code
+}
+
+// HedgingPolicy configures hedged requests: a new attempt of an idempotent
+// RPC is sent every HedgingDelay until one of them succeeds or MaxAttempts
+// attempts are in flight.
+type HedgingPolicy struct {
+	MaxAttempts   int
+	HedgingDelay  time.Duration
+	NonFatalCodes []codes.Code
+}
+
+// MethodPolicy applies to every method of Service, or only to Method when
+// it is set. Timeout is the default deadline of each call, streams
+// included. At most one of Retry and Hedging may be set.
+type MethodPolicy struct {
+	Service string
+	Method  string
+	Timeout time.Duration
+	Retry   *RetryPolicy
+	Hedging *HedgingPolicy
+}
+
+func (p MethodPolicy) fullMethod() string {
+	return "/" + p.Service + "/" + p.Method
+}
+
+var defaultRetry = &RetryPolicy{
+	MaxAttempts:       4,
+	InitialBackoff:    100 * time.Millisecond,
+	MaxBackoff:        2 * time.Second,
+	BackoffMultiplier: 2,
+	RetryableCodes:    []codes.Code{codes.Unavailable},
+}
+
+var defaultHedging = &HedgingPolicy{
+	MaxAttempts:   3,
+	HedgingDelay:  200 * time.Millisecond,
+	NonFatalCodes: []codes.Code{codes.Unavailable, codes.ResourceExhausted},
+}
+
+// DefaultPolicies are the call policies used for the course services.
+// Calls are retried on UNAVAILABLE, except for writes that are not
+// idempotent, and idempotent reads are hedged. Calculator calls may record
+// their results in a session, which the server does once per request ID
+// however many attempts reach it. Unary calls without a timeout here get
+// the connection's default unary timeout.
+var DefaultPolicies = []MethodPolicy{
+	{Service: "greet.GreetService", Retry: defaultRetry},
+
+	{Service: "calculator.CalculatorService", Retry: defaultRetry},
+	{Service: "calculator.CalculatorService", Method: "Sum", Timeout: 5 * time.Second, Hedging: defaultHedging},
+
+	{Service: "blog.BlogService", Retry: defaultRetry},
+	{Service: "blog.BlogService", Method: "CreateBlog"},
+	{Service: "blog.BlogService", Method: "ReadBlog", Timeout: 5 * time.Second, Hedging: defaultHedging},
+}
+
+// serviceConfig renders the policies as a gRPC service config. Hedging is
+// not implemented by grpc-go, so hedged methods only get their timeout
+// here and are handled by the hedging interceptor instead.
+func serviceConfig(policies []MethodPolicy) (string, error) {
+	type name struct {
+		Service string `json:"service"`
+		Method  string `json:"method,omitempty"`
+	}
+	type retryPolicy struct {
+		MaxAttempts          int      `json:"maxAttempts"`
+		InitialBackoff       string   `json:"initialBackoff"`
+		MaxBackoff           string   `json:"maxBackoff"`
+		BackoffMultiplier    float64  `json:"backoffMultiplier"`
+		RetryableStatusCodes []string `json:"retryableStatusCodes"`
+	}
+	type methodConfig struct {
+		Name        []name       `json:"name"`
+		Timeout     string       `json:"timeout,omitempty"`
+		RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+	}
+
+	var cfg struct {
+		MethodConfig []methodConfig `json:"methodConfig"`
+	}
+
+	for _, p := range policies {
+		if p.Retry != nil && p.Hedging != nil {
+			return "", fmt.Errorf("%s: only one of retry and hedging may be set", p.fullMethod())
+		}
+
+		mc := methodConfig{Name: []name{{Service: p.Service, Method: p.Method}}}
+		if p.Timeout > 0 {
+			mc.Timeout = jsonDuration(p.Timeout)
+		}
+		if r := p.Retry; r != nil {
+			mc.RetryPolicy = &retryPolicy{
+				MaxAttempts:          r.MaxAttempts,
+				InitialBackoff:       jsonDuration(r.InitialBackoff),
+				MaxBackoff:           jsonDuration(r.MaxBackoff),
+				BackoffMultiplier:    r.BackoffMultiplier,
+				RetryableStatusCodes: codeNames(r.RetryableCodes),
+			}
+		}
+		cfg.MethodConfig = append(cfg.MethodConfig, mc)
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// jsonDuration formats d the way the service config expects: seconds with
+// an "s" suffix.
+func jsonDuration(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+func codeNames(cs []codes.Code) []string {
+	names := make([]string, len(cs))
+	for i, c := range cs {
+		names[i] = codeName(c)
+	}
+	return names
+}
+
+// codeName returns the canonical upper snake case name of the code, e.g.
+// "UNAVAILABLE", as used in service configs.
+func codeName(c codes.Code) string {
+	switch c {
+	case codes.OK:
+		return "OK"
+	case codes.Canceled:
+		return "CANCELLED"
+	case codes.Unknown:
+		return "UNKNOWN"
+	case codes.InvalidArgument:
+		return "INVALID_ARGUMENT"
+	case codes.DeadlineExceeded:
+		return "DEADLINE_EXCEEDED"
+	case codes.NotFound:
+		return "NOT_FOUND"
+	case codes.AlreadyExists:
+		return "ALREADY_EXISTS"
+	case codes.PermissionDenied:
+		return "PERMISSION_DENIED"
+	case codes.ResourceExhausted:
+		return "RESOURCE_EXHAUSTED"
+	case codes.FailedPrecondition:
+		return "FAILED_PRECONDITION"
+	case codes.Aborted:
+		return "ABORTED"
+	case codes.OutOfRange:
+		return "OUT_OF_RANGE"
+	case codes.Unimplemented:
+		return "UNIMPLEMENTED"
+	case codes.Internal:
+		return "INTERNAL"
+	case codes.Unavailable:
+		return "UNAVAILABLE"
+	case codes.DataLoss:
+		return "DATA_LOSS"
+	case codes.Unauthenticated:
+		return "UNAUTHENTICATED"
+	}
+	return fmt.Sprintf("CODE(%d)", uint32(c))
+}
@@ -0,0 +1,166 @@
+package grpcserver
+
+import (
+	"flag"
+	"strings"
+	"time"
+)
+
+// Config holds the settings shared by every server in the course.
+type Config struct {
+	// Addr is the TCP address the server listens on.
+	Addr string
+
+	// TLS enables transport security using CertFile and KeyFile. When
+	// ClientCAFile is set, clients must present a certificate signed by it.
+	TLS          bool
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+
+	// DrainTimeout is how long in-flight RPCs are given to finish on
+	// shutdown before the server is forcibly stopped.
+	DrainTimeout time.Duration
+
+	// RateLimit and RateBurst configure the token bucket each caller gets
+	// per method. MethodRateLimits overrides them for specific methods.
+	// A zero rate and burst disables rate limiting, and a zero burst with a
+	// positive rate defaults to the rate rounded up.
+	RateLimit        float64
+	RateBurst        int
+	MethodRateLimits MethodLimits
+
+	// MaxStreamsPerClient caps how many streams of each of the
+	// StreamLimitedMethods a single caller may have open at once.
+	MaxStreamsPerClient  int
+	StreamLimitedMethods []string
+
+	// MinDeadline and MaxDeadline bound the deadline of every call, see
+	// DeadlinePolicy. MethodDeadlines overrides them for specific methods.
+	// MaxDeadline does not apply to the LongLivedMethods, streams that stay
+	// open as long as their clients want.
+	MinDeadline      time.Duration
+	MaxDeadline      time.Duration
+	MethodDeadlines  MethodDeadlines
+	LongLivedMethods []string
+
+	// KeepaliveTime is how long a connection may be idle before the server
+	// pings the client, which must answer within KeepaliveTimeout. Pings
+	// keep connections open through proxies that drop idle ones and detect
+	// dead clients. MaxConnectionIdle closes connections without streams
+	// for that long; zero keeps them open.
+	KeepaliveTime     time.Duration
+	KeepaliveTimeout  time.Duration
+	MaxConnectionIdle time.Duration
+
+	// KeepaliveMinTime is the shortest interval at which clients may ping;
+	// clients pinging more often are disconnected. Unless
+	// KeepalivePermitWithoutStream is set, clients may only ping while they
+	// have streams open.
+	KeepaliveMinTime             time.Duration
+	KeepalivePermitWithoutStream bool
+
+	// MaxRecvMsgSize and MaxSendMsgSize limit the size of the messages
+	// received and sent, DefaultMaxRecvMsgSize and DefaultMaxSendMsgSize if
+	// zero. MethodMessageSizes overrides them for specific methods. Larger
+	// messages fail the call with RESOURCE_EXHAUSTED.
+	MaxRecvMsgSize     ByteSize
+	MaxSendMsgSize     ByteSize
+	MethodMessageSizes MethodMessageSizes
+
+	// MetricsAddr is the address of the HTTP server exposing the
+	// compression metrics at /debug/vars. The server is neither encrypted
+	// nor authenticated, so the address should only be reachable by the
+	// monitoring system, e.g. localhost:9090. Empty disables it.
+	MetricsAddr string
+}
+
+// DefaultConfig returns the configuration used when no flags are given.
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:               addr,
+		CertFile:           "ssl/server.crt",
+		KeyFile:            "ssl/server.pem",
+		DrainTimeout:       30 * time.Second,
+		KeepaliveTime:      time.Minute,
+		KeepaliveTimeout:   20 * time.Second,
+		KeepaliveMinTime:   10 * time.Second,
+		MethodRateLimits:   MethodLimits{},
+		MethodDeadlines:    MethodDeadlines{},
+		MaxRecvMsgSize:     DefaultMaxRecvMsgSize,
+		MaxSendMsgSize:     DefaultMaxSendMsgSize,
+		MethodMessageSizes: MethodMessageSizes{},
+		LongLivedMethods: []string{
+			"/greet.GreetService/GreetEveryone",
+			"/greet.GreetService/JoinRoom",
+			"/calculator.CalculatorService/FindMaximum",
+			"/calculator.CalculatorService/RunningAggregate",
+		},
+		StreamLimitedMethods: []string{
+			"/greet.GreetService/GreetEveryone",
+			"/greet.GreetService/JoinRoom",
+			"/calculator.CalculatorService/FindMaximum",
+			"/blog.BlogService/ListBlog",
+		},
+	}
+}
+
+// RegisterFlags binds the configuration to command line flags, using the
+// current values as defaults.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Addr, "addr", c.Addr, "address to listen on")
+	fs.BoolVar(&c.TLS, "tls", c.TLS, "enable TLS")
+	fs.StringVar(&c.CertFile, "tls-cert", c.CertFile, "TLS certificate file")
+	fs.StringVar(&c.KeyFile, "tls-key", c.KeyFile, "TLS private key file")
+	fs.StringVar(&c.ClientCAFile, "tls-client-ca", c.ClientCAFile, "CA file used to verify client certificates (enables mTLS)")
+	fs.DurationVar(&c.DrainTimeout, "drain-timeout", c.DrainTimeout, "time to wait for in-flight RPCs on shutdown")
+
+	if c.MethodRateLimits == nil {
+		c.MethodRateLimits = MethodLimits{}
+	}
+	fs.Float64Var(&c.RateLimit, "rate-limit", c.RateLimit, "requests per second allowed per caller and method (0 disables)")
+	fs.IntVar(&c.RateBurst, "rate-burst", c.RateBurst, "burst size of the per caller rate limit (0 for the rate rounded up)")
+	fs.Var(c.MethodRateLimits, "rate-limit-method", "per method rate limit as /pkg.Service/Method=rate:burst (repeatable)")
+	fs.IntVar(&c.MaxStreamsPerClient, "max-streams-per-client", c.MaxStreamsPerClient, "concurrent streams allowed per caller on stream limited methods (0 disables)")
+	fs.Var((*stringList)(&c.StreamLimitedMethods), "stream-limited-methods", "comma separated methods subject to -max-streams-per-client")
+
+	if c.MethodDeadlines == nil {
+		c.MethodDeadlines = MethodDeadlines{}
+	}
+	fs.DurationVar(&c.MinDeadline, "min-deadline", c.MinDeadline, "reject calls with less time left than this (0 disables)")
+	fs.DurationVar(&c.MaxDeadline, "max-deadline", c.MaxDeadline, "cap the deadline of calls, including calls without one (0 disables)")
+	fs.Var((*stringList)(&c.LongLivedMethods), "long-lived-methods", "comma separated streams exempt from -max-deadline")
+	fs.Var(c.MethodDeadlines, "deadline-method", "per method deadline bounds as /pkg.Service/Method=min:max, either may be empty (repeatable)")
+
+	fs.DurationVar(&c.KeepaliveTime, "keepalive-time", c.KeepaliveTime, "idle time after which the server pings the client")
+	fs.DurationVar(&c.KeepaliveTimeout, "keepalive-timeout", c.KeepaliveTimeout, "time the client has to answer a keepalive ping")
+	fs.DurationVar(&c.MaxConnectionIdle, "max-connection-idle", c.MaxConnectionIdle, "close connections without streams for this long (0 keeps them)")
+	fs.DurationVar(&c.KeepaliveMinTime, "keepalive-min-time", c.KeepaliveMinTime, "shortest interval at which clients may ping")
+	fs.BoolVar(&c.KeepalivePermitWithoutStream, "keepalive-permit-without-stream", c.KeepalivePermitWithoutStream, "allow clients to ping without open streams")
+
+	if c.MethodMessageSizes == nil {
+		c.MethodMessageSizes = MethodMessageSizes{}
+	}
+	fs.Var(&c.MaxRecvMsgSize, "max-recv-msg-size", "largest message received, e.g. 4MiB")
+	fs.Var(&c.MaxSendMsgSize, "max-send-msg-size", "largest message sent, e.g. 4MiB")
+	fs.Var(c.MethodMessageSizes, "msg-size-method", "per method message size limits as /pkg.Service/Method=recv:send, either may be empty (repeatable)")
+
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "address of the unauthenticated HTTP server exposing metrics at /debug/vars, e.g. localhost:9090 (empty disables)")
+}
+
+// stringList is a flag.Value for comma separated lists.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = nil
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*l = append(*l, s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DeadlineTooShort is the reason of the google.rpc.ErrorInfo detail of the
+// FAILED_PRECONDITION errors returned for calls with too little time left.
+const DeadlineTooShort = "DEADLINE_TOO_SHORT"
+
+// DeadlinePolicy bounds the deadlines of incoming calls. Calls with less
+// time left than Min are rejected, and calls without a deadline or with
+// more time left than Max are given one of Max. Zero disables a bound.
+type DeadlinePolicy struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+// MethodDeadlines maps full method names to their deadline policy. It
+// implements flag.Value, accepting repeated "/pkg.Service/Method=min:max"
+// values where either bound may be empty.
+type MethodDeadlines map[string]DeadlinePolicy
+
+func (m MethodDeadlines) String() string {
+	var parts []string
+	for method, p := range m {
+		parts = append(parts, fmt.Sprintf("%s=%v:%v", method, p.Min, p.Max))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a single "/pkg.Service/Method=min:max" override.
+func (m MethodDeadlines) Set(v string) error {
+	i := strings.LastIndex(v, "=")
+	if i < 0 {
+		return fmt.Errorf("expected method=min:max, got %q", v)
+	}
+	method, policy := v[:i], v[i+1:]
+
+	j := strings.Index(policy, ":")
+	if j < 0 {
+		return fmt.Errorf("expected min:max, got %q", policy)
+	}
+
+	var p DeadlinePolicy
+	var err error
+	if min := policy[:j]; min != "" {
+		if p.Min, err = time.ParseDuration(min); err != nil {
+			return fmt.Errorf("invalid minimum deadline %q: %v", min, err)
+		}
+	}
+	if max := policy[j+1:]; max != "" {
+		if p.Max, err = time.ParseDuration(max); err != nil {
+			return fmt.Errorf("invalid maximum deadline %q: %v", max, err)
+		}
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	m[method] = p
+	return nil
+}
+
+func (p DeadlinePolicy) validate() error {
+	if p.Min < 0 || p.Max < 0 {
+		return fmt.Errorf("negative deadline bound %v:%v", p.Min, p.Max)
+	}
+	if p.Max > 0 && p.Min > p.Max {
+		return fmt.Errorf("minimum deadline %v above maximum %v", p.Min, p.Max)
+	}
+	return nil
+}
+
+// deadlineEnforcer applies the deadline policies to incoming calls.
+type deadlineEnforcer struct {
+	defaultPolicy  DeadlinePolicy
+	methodPolicies MethodDeadlines
+	longLived      map[string]bool
+}
+
+func newDeadlineEnforcer(cfg Config) (*deadlineEnforcer, error) {
+	d := &deadlineEnforcer{
+		defaultPolicy:  DeadlinePolicy{Min: cfg.MinDeadline, Max: cfg.MaxDeadline},
+		methodPolicies: cfg.MethodDeadlines,
+		longLived:      make(map[string]bool),
+	}
+	if err := d.defaultPolicy.validate(); err != nil {
+		return nil, err
+	}
+	for _, m := range cfg.LongLivedMethods {
+		d.longLived[m] = true
+	}
+	return d, nil
+}
+
+// apply returns ctx bounded by the policy of method. The returned function
+// releases the resources of the bounded context.
+func (d *deadlineEnforcer) apply(ctx context.Context, method string) (context.Context, context.CancelFunc, error) {
+	p, ok := d.methodPolicies[method]
+	if !ok {
+		p = d.defaultPolicy
+		if d.longLived[method] {
+			p.Max = 0
+		}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if p.Min > 0 && ok {
+		if left := time.Until(deadline); left < p.Min {
+			st := status.Newf(codes.FailedPrecondition, "Deadline too short for %s: %v left, at least %v required", method, left.Round(time.Millisecond), p.Min)
+			detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+				Reason: DeadlineTooShort,
+				Domain: "grpc-go-course",
+				Metadata: map[string]string{
+					"method": method,
+					"min":    p.Min.String(),
+				},
+			})
+			if err == nil {
+				st = detailed
+			}
+			return nil, nil, st.Err()
+		}
+	}
+
+	if p.Max > 0 && (!ok || time.Until(deadline) > p.Max) {
+		ctx, cancel := context.WithTimeout(ctx, p.Max)
+		return ctx, cancel, nil
+	}
+	return ctx, func() {}, nil
+}
+
+func (d *deadlineEnforcer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, cancel, err := d.apply(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	return handler(ctx, req)
+}
+
+func (d *deadlineEnforcer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel, err := d.apply(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// contextStream is a grpc.ServerStream with a replaced context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
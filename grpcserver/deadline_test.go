@@ -0,0 +1,159 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeadlineEnforcerApply(t *testing.T) {
+	const (
+		greet = "/greet.GreetService/Greet"
+		room  = "/greet.GreetService/JoinRoom"
+	)
+	cfg := Config{
+		MinDeadline:      100 * time.Millisecond,
+		MaxDeadline:      time.Second,
+		MethodDeadlines:  MethodDeadlines{"/blog.BlogService/ListBlog": {Max: time.Minute}},
+		LongLivedMethods: []string{room},
+	}
+	d, err := newDeadlineEnforcer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		timeout time.Duration // 0 for no deadline
+		want    codes.Code
+		// wantMax is the longest time left after apply, 0 for no deadline
+		wantMax time.Duration
+	}{
+		{"within bounds", greet, 500 * time.Millisecond, codes.OK, 500 * time.Millisecond},
+		{"too short", greet, 10 * time.Millisecond, codes.FailedPrecondition, 0},
+		{"capped", greet, time.Hour, codes.OK, time.Second},
+		{"no deadline", greet, 0, codes.OK, time.Second},
+		{"method override", "/blog.BlogService/ListBlog", time.Hour, codes.OK, time.Minute},
+		{"method override without min", "/blog.BlogService/ListBlog", 10 * time.Millisecond, codes.OK, 10 * time.Millisecond},
+		{"long-lived without deadline", room, 0, codes.OK, 0},
+		{"long-lived keeps its deadline", room, time.Hour, codes.OK, time.Hour},
+		{"long-lived too short", room, 10 * time.Millisecond, codes.FailedPrecondition, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			ctx, cancel, err := d.apply(ctx, tt.method)
+			if got := status.Code(err); got != tt.want {
+				t.Fatalf("apply() = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if tt.wantMax == 0 {
+				if ok {
+					t.Errorf("deadline in %v, want none", time.Until(deadline))
+				}
+				return
+			}
+			if left := time.Until(deadline); !ok || left > tt.wantMax || left < tt.wantMax-50*time.Millisecond {
+				t.Errorf("deadline in %v (set %v), want %v", left, ok, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestNewDeadlineEnforcerValidates(t *testing.T) {
+	tests := []struct {
+		min, max time.Duration
+		ok       bool
+	}{
+		{0, 0, true},
+		{time.Second, 0, true},
+		{time.Second, time.Minute, true},
+		{time.Minute, time.Second, false},
+		{-time.Second, 0, false},
+	}
+	for _, tt := range tests {
+		_, err := newDeadlineEnforcer(Config{MinDeadline: tt.min, MaxDeadline: tt.max})
+		if (err == nil) != tt.ok {
+			t.Errorf("min %v, max %v: err = %v, want ok %v", tt.min, tt.max, err, tt.ok)
+		}
+	}
+}
+
+func TestMethodDeadlinesSet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want DeadlinePolicy
+		ok   bool
+	}{
+		{"/a.S/M=1s:1m", DeadlinePolicy{Min: time.Second, Max: time.Minute}, true},
+		{"/a.S/M=:1m", DeadlinePolicy{Max: time.Minute}, true},
+		{"/a.S/M=1s:", DeadlinePolicy{Min: time.Second}, true},
+		{"/a.S/M=1m:1s", DeadlinePolicy{}, false},
+		{"/a.S/M=1s", DeadlinePolicy{}, false},
+		{"/a.S/M=x:", DeadlinePolicy{}, false},
+	}
+	for _, tt := range tests {
+		m := MethodDeadlines{}
+		err := m.Set(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Set(%q) = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && m["/a.S/M"] != tt.want {
+			t.Errorf("Set(%q) = %+v, want %+v", tt.in, m["/a.S/M"], tt.want)
+		}
+	}
+}
+
+// serverStream is a grpc.ServerStream that only has a context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestDeadlineEnforcerDefaultLongLived(t *testing.T) {
+	cfg := DefaultConfig(":0")
+	cfg.MaxDeadline = time.Second
+	d, err := newDeadlineEnforcer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, method := range []string{
+		"/calculator.CalculatorService/FindMaximum",
+		"/calculator.CalculatorService/RunningAggregate",
+	} {
+		t.Run(method, func(t *testing.T) {
+			info := &grpc.StreamServerInfo{FullMethod: method, IsClientStream: true, IsServerStream: true}
+			err := d.stream(nil, &serverStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+				if deadline, ok := ss.Context().Deadline(); ok {
+					t.Errorf("deadline in %v, want none", time.Until(deadline))
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
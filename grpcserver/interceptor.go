@@ -0,0 +1,24 @@
+package grpcserver
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+func unaryLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	log.Printf("method=%s code=%s duration=%v", info.FullMethod, status.Code(err), time.Since(start))
+	return res, err
+}
+
+func streamLogger(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	log.Printf("method=%s code=%s duration=%v", info.FullMethod, status.Code(err), time.Since(start))
+	return err
+}
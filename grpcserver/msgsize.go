@@ -0,0 +1,264 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/compression"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Default message size limits, those of gRPC for received messages.
+const (
+	DefaultMaxRecvMsgSize ByteSize = 4 << 20
+	DefaultMaxSendMsgSize ByteSize = 4 << 20
+)
+
+// MessageTooLarge is the reason of the google.rpc.ErrorInfo detail of the
+// errors returned for messages over the size limits.
+const MessageTooLarge = "MESSAGE_TOO_LARGE"
+
+// ByteSize is a size in bytes. It implements flag.Value, accepting plain
+// byte counts as well as the KiB, MiB and GiB suffixes, e.g. "512KiB".
+type ByteSize int
+
+// maxByteSize is the largest ByteSize.
+const maxByteSize = ByteSize(^uint(0) >> 1)
+
+var sizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GiB", 1 << 30},
+	{"MiB", 1 << 20},
+	{"KiB", 1 << 10},
+}
+
+func (b ByteSize) String() string {
+	for _, u := range sizeUnits {
+		if b >= u.size && b%u.size == 0 {
+			return fmt.Sprintf("%d%s", b/u.size, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int(b))
+}
+
+func (b *ByteSize) Set(v string) error {
+	size, err := parseByteSize(v)
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+func parseByteSize(v string) (ByteSize, error) {
+	unit := ByteSize(1)
+	n := strings.TrimSuffix(v, "B")
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			unit, n = u.size, strings.TrimSuffix(v, u.suffix)
+			break
+		}
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid size %q", v)
+	}
+	if ByteSize(i) > maxByteSize/unit {
+		return 0, fmt.Errorf("size %q is too large", v)
+	}
+	return ByteSize(i) * unit, nil
+}
+
+// MessageSizes limits the size of the messages of a method. Zero uses the
+// server's defaults.
+type MessageSizes struct {
+	Recv ByteSize
+	Send ByteSize
+}
+
+// MethodMessageSizes maps full method names to their message size limits.
+// It implements flag.Value, accepting repeated
+// "/pkg.Service/Method=recv:send" values where either limit may be empty.
+type MethodMessageSizes map[string]MessageSizes
+
+func (m MethodMessageSizes) String() string {
+	var parts []string
+	for method, s := range m {
+		parts = append(parts, fmt.Sprintf("%s=%v:%v", method, s.Recv, s.Send))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a single "/pkg.Service/Method=recv:send" override.
+func (m MethodMessageSizes) Set(v string) error {
+	i := strings.LastIndex(v, "=")
+	if i < 0 {
+		return fmt.Errorf("expected method=recv:send, got %q", v)
+	}
+	method, sizes := v[:i], v[i+1:]
+
+	j := strings.Index(sizes, ":")
+	if j < 0 {
+		return fmt.Errorf("expected recv:send, got %q", sizes)
+	}
+
+	var s MessageSizes
+	var err error
+	if recv := sizes[:j]; recv != "" {
+		if s.Recv, err = parseByteSize(recv); err != nil {
+			return err
+		}
+	}
+	if send := sizes[j+1:]; send != "" {
+		if s.Send, err = parseByteSize(send); err != nil {
+			return err
+		}
+	}
+
+	m[method] = s
+	return nil
+}
+
+// sizeLimiter enforces the message size limits of each method. gRPC only
+// knows of one limit per server, which is set to the largest of them, so
+// calls to methods with lower limits get a descriptive error from the
+// interceptors rather than being cut off by the transport.
+type sizeLimiter struct {
+	defaults MessageSizes
+	methods  MethodMessageSizes
+}
+
+func newSizeLimiter(cfg Config) (*sizeLimiter, error) {
+	l := &sizeLimiter{
+		defaults: MessageSizes{Recv: cfg.MaxRecvMsgSize, Send: cfg.MaxSendMsgSize},
+		methods:  cfg.MethodMessageSizes,
+	}
+	if l.defaults.Recv <= 0 {
+		l.defaults.Recv = DefaultMaxRecvMsgSize
+	}
+	if l.defaults.Send <= 0 {
+		l.defaults.Send = DefaultMaxSendMsgSize
+	}
+
+	// gRPC checks the size of compressed messages once decoded, but
+	// decoders give up at compression.MaxDecodedSize already.
+	if recv := l.transport().Recv; recv > compression.MaxDecodedSize {
+		return nil, fmt.Errorf("max receive message size %v above the %v compressed messages may decode to", recv, ByteSize(compression.MaxDecodedSize))
+	}
+	return l, nil
+}
+
+// limits returns the size limits of method.
+func (l *sizeLimiter) limits(method string) MessageSizes {
+	s := l.methods[method]
+	if s.Recv <= 0 {
+		s.Recv = l.defaults.Recv
+	}
+	if s.Send <= 0 {
+		s.Send = l.defaults.Send
+	}
+	return s
+}
+
+// transport returns the limits given to gRPC, the largest of all methods.
+func (l *sizeLimiter) transport() MessageSizes {
+	t := l.defaults
+	for method := range l.methods {
+		s := l.limits(method)
+		if s.Recv > t.Recv {
+			t.Recv = s.Recv
+		}
+		if s.Send > t.Send {
+			t.Send = s.Send
+		}
+	}
+	return t
+}
+
+func (l *sizeLimiter) serverOptions() []grpc.ServerOption {
+	t := l.transport()
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(t.Recv)),
+		grpc.MaxSendMsgSize(int(t.Send)),
+	}
+}
+
+// checkSize returns a RESOURCE_EXHAUSTED error if msg is larger than limit.
+func checkSize(method, kind string, msg interface{}, limit ByteSize) error {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	size := ByteSize(proto.Size(m))
+	if size <= limit {
+		return nil
+	}
+
+	st := status.Newf(codes.ResourceExhausted, "%s of %s is %v, larger than the limit of %v", kind, method, size, limit)
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: MessageTooLarge,
+		Domain: "grpc-go-course",
+		Metadata: map[string]string{
+			"method": method,
+			"size":   strconv.Itoa(int(size)),
+			"limit":  strconv.Itoa(int(limit)),
+		},
+	})
+	if err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (l *sizeLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	limits := l.limits(info.FullMethod)
+	if err := checkSize(info.FullMethod, "Request", req, limits.Recv); err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSize(info.FullMethod, "Response", resp, limits.Send); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (l *sizeLimiter) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &sizeLimitedStream{
+		ServerStream: ss,
+		method:       info.FullMethod,
+		limits:       l.limits(info.FullMethod),
+	})
+}
+
+// sizeLimitedStream checks the size of the messages of a stream.
+type sizeLimitedStream struct {
+	grpc.ServerStream
+	method string
+	limits MessageSizes
+}
+
+func (s *sizeLimitedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkSize(s.method, "Request", m, s.limits.Recv)
+}
+
+func (s *sizeLimitedStream) SendMsg(m interface{}) error {
+	if err := checkSize(s.method, "Response", m, s.limits.Send); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
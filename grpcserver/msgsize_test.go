@@ -0,0 +1,60 @@
+package grpcserver
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"512", 512, true},
+		{"512B", 512, true},
+		{"4KiB", 4 << 10, true},
+		{"16MiB", 16 << 20, true},
+		{"2GiB", 2 << 30, true},
+		{" 3 MiB", 3 << 20, true},
+		{"-1", 0, false},
+		{"1.5MiB", 0, false},
+		{"MiB", 0, false},
+		{"1TiB", 0, false},
+		{strconv.Itoa(int(maxByteSize)), maxByteSize, true},
+		{strconv.Itoa(int(maxByteSize/(1<<30))) + "GiB", maxByteSize / (1 << 30) * (1 << 30), true},
+		{strconv.Itoa(int(maxByteSize/(1<<30))+1) + "GiB", 0, false},
+		{strconv.Itoa(int(maxByteSize/(1<<10))+1) + "KiB", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseByteSize(tt.in)
+		if tt.ok != (err == nil) || got != tt.want {
+			t.Errorf("parseByteSize(%q) = %v, %v, want %v (ok %v)", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestByteSizeString(t *testing.T) {
+	for _, b := range []ByteSize{0, 1, 1000, 1 << 10, 3 << 20, 3<<20 + 1, 2 << 30} {
+		got, err := parseByteSize(b.String())
+		if err != nil || got != b {
+			t.Errorf("parseByteSize(%q) = %v, %v, want %d", b.String(), got, err, int(b))
+		}
+	}
+}
+
+func TestMethodMessageSizesSet(t *testing.T) {
+	m := MethodMessageSizes{}
+	if err := m.Set("/blog.BlogService/CreateBlog=16MiB:"); err != nil {
+		t.Fatal(err)
+	}
+	if got := m["/blog.BlogService/CreateBlog"]; got != (MessageSizes{Recv: 16 << 20}) {
+		t.Errorf("sizes = %+v", got)
+	}
+	for _, v := range []string{"/a/B", "/a/B=1MiB", "/a/B=x:", "/a/B=:99999999999999999999GiB"} {
+		if err := m.Set(v); err == nil {
+			t.Errorf("Set(%q) succeeded", v)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// ClientIDKey is the metadata key clients use to identify themselves when
+// they do not present a TLS client certificate.
+const ClientIDKey = "x-client-id"
+
+// Limit is a token bucket configuration: Rate tokens are added per second,
+// up to Burst tokens. A positive rate without a burst gets a burst of one
+// second's worth of tokens, at least one, so that calls can go through.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// withDefaultBurst returns l with the default burst if it has none.
+func (l Limit) withDefaultBurst() Limit {
+	if l.Rate > 0 && l.Burst <= 0 {
+		l.Burst = int(math.Ceil(l.Rate))
+		if l.Burst < 1 {
+			l.Burst = 1
+		}
+	}
+	return l
+}
+
+// MethodLimits maps full method names to their rate limit. It implements
+// flag.Value, accepting repeated "/pkg.Service/Method=rate:burst" values.
+type MethodLimits map[string]Limit
+
+func (m MethodLimits) String() string {
+	var parts []string
+	for method, l := range m {
+		parts = append(parts, fmt.Sprintf("%s=%v:%d", method, l.Rate, l.Burst))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a single "/pkg.Service/Method=rate:burst" override.
+func (m MethodLimits) Set(v string) error {
+	i := strings.LastIndex(v, "=")
+	if i < 0 {
+		return fmt.Errorf("expected method=rate:burst, got %q", v)
+	}
+	method, limit := v[:i], v[i+1:]
+
+	j := strings.Index(limit, ":")
+	if j < 0 {
+		return fmt.Errorf("expected rate:burst, got %q", limit)
+	}
+	rate, burst := limit[:j], limit[j+1:]
+
+	var l Limit
+	var err error
+	if l.Rate, err = strconv.ParseFloat(rate, 64); err != nil {
+		return fmt.Errorf("invalid rate %q: %v", rate, err)
+	}
+	if l.Burst, err = strconv.Atoi(burst); err != nil {
+		return fmt.Errorf("invalid burst %q: %v", burst, err)
+	}
+	if l.Rate < 0 || l.Burst < 0 {
+		return fmt.Errorf("negative rate limit %q", limit)
+	}
+
+	m[method] = l
+	return nil
+}
+
+// maxIDsPerPeer is the number of x-client-id values a peer IP address may
+// have buckets for on each method. Calls with further IDs share the bucket
+// of the address, so that rotating IDs neither escapes the rate limit nor
+// grows the buckets without bound.
+const maxIDsPerPeer = 16
+
+// caller identifies the client of an RPC.
+type caller struct {
+	// id keys the buckets and stream counts of the client.
+	id string
+	// peer is the id of the client's IP address when id is an unverified
+	// x-client-id, and empty otherwise.
+	peer string
+}
+
+// callerIdentity identifies the client of an RPC. A verified TLS client
+// certificate takes precedence over the x-client-id metadata, which in turn
+// takes precedence over the peer IP address. As anyone can send any
+// x-client-id, it is scoped to the IP address.
+func callerIdentity(ctx context.Context) caller {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return caller{id: "unknown"}
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if cert := verifiedLeaf(tlsInfo.State.VerifiedChains); cert != nil {
+			return caller{id: "cert:" + cert.Subject.CommonName}
+		}
+	}
+
+	ip := "ip:" + p.Addr.String()
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		ip = "ip:" + host
+	}
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(ClientIDKey); len(ids) > 0 && ids[0] != "" {
+			return caller{id: ip + "|id:" + ids[0], peer: ip}
+		}
+	}
+	return caller{id: ip}
+}
+
+func verifiedLeaf(chains [][]*x509.Certificate) *x509.Certificate {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return nil
+	}
+	return chains[0][0]
+}
+
+type tokenBucket struct {
+	limit    Limit
+	tokens   float64
+	last     time.Time
+	lastUsed time.Time
+	// peerKey is the key of the ID count of the peer the bucket belongs
+	// to, for the buckets of x-client-id values.
+	peerKey string
+}
+
+// take consumes a token. If none is available, it returns how long the
+// caller has to wait for the next one.
+func (b *tokenBucket) take(now time.Time) (bool, time.Duration) {
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if max := float64(b.limit.Burst); b.tokens > max {
+		b.tokens = max
+	}
+	b.last = now
+	b.lastUsed = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if b.limit.Rate <= 0 {
+		return false, time.Minute
+	}
+	wait := time.Duration((1 - b.tokens) / b.limit.Rate * float64(time.Second))
+	return false, wait
+}
+
+// rateLimiter keeps a token bucket per method and caller, plus a count of
+// the open streams of each caller on the stream limited methods.
+type rateLimiter struct {
+	defaultLimit  Limit
+	methodLimits  MethodLimits
+	maxStreams    int
+	streamMethods map[string]bool
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	// peerIDs counts the buckets of x-client-id values of each method and
+	// peer.
+	peerIDs   map[string]int
+	streams   map[string]int
+	lastSweep time.Time
+}
+
+func newRateLimiter(cfg Config) *rateLimiter {
+	streamMethods := make(map[string]bool)
+	for _, m := range cfg.StreamLimitedMethods {
+		streamMethods[m] = true
+	}
+
+	methodLimits := make(MethodLimits, len(cfg.MethodRateLimits))
+	for method, limit := range cfg.MethodRateLimits {
+		methodLimits[method] = limit.withDefaultBurst()
+	}
+
+	return &rateLimiter{
+		defaultLimit:  Limit{Rate: cfg.RateLimit, Burst: cfg.RateBurst}.withDefaultBurst(),
+		methodLimits:  methodLimits,
+		maxStreams:    cfg.MaxStreamsPerClient,
+		streamMethods: streamMethods,
+		buckets:       make(map[string]*tokenBucket),
+		peerIDs:       make(map[string]int),
+		streams:       make(map[string]int),
+	}
+}
+
+// idleBucketTTL is how long an unused bucket is kept around. By then it
+// has refilled completely, so dropping it does not change the outcome.
+const idleBucketTTL = 10 * time.Minute
+
+func (l *rateLimiter) allow(method string, c caller) error {
+	limit, ok := l.methodLimits[method]
+	if !ok {
+		limit = l.defaultLimit
+	}
+	if limit.Rate <= 0 && limit.Burst <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	key := method + "|" + c.id
+
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastUsed) > idleBucketTTL {
+				l.deleteBucket(k, b)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		var peerKey string
+		if c.peer != "" {
+			peerKey = method + "|" + c.peer
+			if l.peerIDs[peerKey] >= maxIDsPerPeer {
+				// the peer has too many IDs: charge its address instead
+				key, peerKey = peerKey, ""
+				b, ok = l.buckets[key]
+			}
+		}
+		if !ok {
+			b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now, peerKey: peerKey}
+			l.buckets[key] = b
+			if peerKey != "" {
+				l.peerIDs[peerKey]++
+			}
+		}
+	}
+	allowed, wait := b.take(now)
+	l.mu.Unlock()
+
+	if allowed {
+		return nil
+	}
+
+	st := status.Newf(codes.ResourceExhausted, "Rate limit exceeded for %s: %v requests/s with bursts of %d", method, limit.Rate, limit.Burst)
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// deleteBucket removes the bucket b stored under key. l.mu must be held.
+func (l *rateLimiter) deleteBucket(key string, b *tokenBucket) {
+	delete(l.buckets, key)
+	if b.peerKey == "" {
+		return
+	}
+	if l.peerIDs[b.peerKey]--; l.peerIDs[b.peerKey] <= 0 {
+		delete(l.peerIDs, b.peerKey)
+	}
+}
+
+// acquireStream reserves a stream slot for the caller. The returned
+// function releases it.
+func (l *rateLimiter) acquireStream(method string, c caller) (func(), error) {
+	if l.maxStreams <= 0 || !l.streamMethods[method] {
+		return func() {}, nil
+	}
+
+	key := method + "|" + c.id
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.streams[key] >= l.maxStreams {
+		return nil, status.Errorf(codes.ResourceExhausted, "Too many concurrent streams for %s: at most %d per client", method, l.maxStreams)
+	}
+	l.streams[key]++
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.streams[key]--; l.streams[key] <= 0 {
+			delete(l.streams, key)
+		}
+	}, nil
+}
+
+func (l *rateLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.allow(info.FullMethod, callerIdentity(ctx)); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *rateLimiter) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	c := callerIdentity(ss.Context())
+	if err := l.allow(info.FullMethod, c); err != nil {
+		return err
+	}
+
+	release, err := l.acquireStream(info.FullMethod, c)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return handler(srv, ss)
+}
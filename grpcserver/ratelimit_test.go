@@ -0,0 +1,208 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestLimitWithDefaultBurst(t *testing.T) {
+	tests := []struct {
+		in, want Limit
+	}{
+		{Limit{}, Limit{}},
+		{Limit{Rate: 10}, Limit{Rate: 10, Burst: 10}},
+		{Limit{Rate: 2.5}, Limit{Rate: 2.5, Burst: 3}},
+		{Limit{Rate: 0.1}, Limit{Rate: 0.1, Burst: 1}},
+		{Limit{Rate: 10, Burst: 4}, Limit{Rate: 10, Burst: 4}},
+		{Limit{Burst: 4}, Limit{Burst: 4}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.withDefaultBurst(); got != tt.want {
+			t.Errorf("%+v.withDefaultBurst() = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Unix(0, 0)
+	tests := []struct {
+		name  string
+		limit Limit
+		// calls are offsets from start, each expecting allowed or not
+		calls   []time.Duration
+		allowed []bool
+	}{
+		{
+			name:    "burst then refill",
+			limit:   Limit{Rate: 10, Burst: 2},
+			calls:   []time.Duration{0, 0, 0, 50 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+			allowed: []bool{true, true, false, false, true, false},
+		},
+		{
+			name:    "refill capped at burst",
+			limit:   Limit{Rate: 10, Burst: 1},
+			calls:   []time.Duration{0, time.Hour, time.Hour},
+			allowed: []bool{true, true, false},
+		},
+		{
+			name:    "burst only",
+			limit:   Limit{Burst: 2},
+			calls:   []time.Duration{0, 0, time.Hour},
+			allowed: []bool{true, true, false},
+		},
+		{
+			name:    "default burst",
+			limit:   Limit{Rate: 10}.withDefaultBurst(),
+			calls:   []time.Duration{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, time.Second},
+			allowed: []bool{true, true, true, true, true, true, true, true, true, true, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &tokenBucket{limit: tt.limit, tokens: float64(tt.limit.Burst), last: start}
+			for i, at := range tt.calls {
+				allowed, wait := b.take(start.Add(at))
+				if allowed != tt.allowed[i] {
+					t.Fatalf("call %d at %v: allowed = %v, want %v", i, at, allowed, tt.allowed[i])
+				}
+				if !allowed && wait <= 0 {
+					t.Errorf("call %d at %v: wait = %v, want > 0", i, at, wait)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	const method = "/greet.GreetService/Greet"
+	tests := []struct {
+		name    string
+		cfg     Config
+		calls   int
+		allowed int
+	}{
+		{"disabled", Config{}, 100, 100},
+		{"rate without burst", Config{RateLimit: 10}, 20, 10},
+		{"burst", Config{RateLimit: 1, RateBurst: 3}, 5, 3},
+		{"method override without burst", Config{MethodRateLimits: MethodLimits{method: {Rate: 5}}}, 10, 5},
+		{"method override disables", Config{RateLimit: 1, MethodRateLimits: MethodLimits{method: {}}}, 10, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRateLimiter(tt.cfg)
+			allowed := 0
+			for i := 0; i < tt.calls; i++ {
+				err := l.allow(method, caller{id: "ip:127.0.0.1"})
+				if err == nil {
+					allowed++
+				} else if status.Code(err) != codes.ResourceExhausted {
+					t.Fatalf("allow() = %v, want RESOURCE_EXHAUSTED", err)
+				}
+			}
+			if allowed != tt.allowed {
+				t.Errorf("%d of %d calls allowed, want %d", allowed, tt.calls, tt.allowed)
+			}
+		})
+	}
+}
+
+// callFrom returns the context of a call from ip, with the given
+// x-client-id unless it is empty.
+func callFrom(ip, id string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}})
+	if id != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(ClientIDKey, id))
+	}
+	return ctx
+}
+
+func TestCallerIdentity(t *testing.T) {
+	tests := []struct {
+		ctx  context.Context
+		want caller
+	}{
+		{context.Background(), caller{id: "unknown"}},
+		{callFrom("10.0.0.1", ""), caller{id: "ip:10.0.0.1"}},
+		{callFrom("10.0.0.1", "alice"), caller{id: "ip:10.0.0.1|id:alice", peer: "ip:10.0.0.1"}},
+		{callFrom("10.0.0.2", "alice"), caller{id: "ip:10.0.0.2|id:alice", peer: "ip:10.0.0.2"}},
+	}
+	for _, tt := range tests {
+		if got := callerIdentity(tt.ctx); got != tt.want {
+			t.Errorf("callerIdentity() = %+v, want %+v", got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterRotatingIDs(t *testing.T) {
+	const method = "/greet.GreetService/Greet"
+	const burst = 2
+	l := newRateLimiter(Config{RateLimit: 0.001, RateBurst: burst})
+
+	allowed := 0
+	for i := 0; i < 1000; i++ {
+		if l.allow(method, callerIdentity(callFrom("10.0.0.1", fmt.Sprint("id-", i)))) == nil {
+			allowed++
+		}
+	}
+	// each of the first IDs gets a call out of its burst, the others share
+	// the burst of the address
+	if want := maxIDsPerPeer + burst; allowed != want {
+		t.Errorf("%d calls with rotating IDs allowed, want %d", allowed, want)
+	}
+	if n := len(l.buckets); n != maxIDsPerPeer+1 {
+		t.Errorf("%d buckets for one peer, want %d", n, maxIDsPerPeer+1)
+	}
+
+	// another peer is unaffected, and so are the IDs that have a bucket
+	if err := l.allow(method, callerIdentity(callFrom("10.0.0.2", "id-1"))); err != nil {
+		t.Errorf("call from another peer = %v", err)
+	}
+
+	// idle buckets are swept along with their count
+	l.mu.Lock()
+	for _, b := range l.buckets {
+		b.lastUsed = b.lastUsed.Add(-2 * idleBucketTTL)
+	}
+	l.lastSweep = time.Time{}
+	l.mu.Unlock()
+	if err := l.allow(method, callerIdentity(callFrom("10.0.0.1", "fresh"))); err != nil {
+		t.Errorf("call with a new ID after the sweep = %v", err)
+	}
+	if n := len(l.peerIDs); n != 1 || l.peerIDs[method+"|ip:10.0.0.1"] != 1 {
+		t.Errorf("ID counts after the sweep = %v", l.peerIDs)
+	}
+}
+
+func TestMethodLimitsSet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+		ok   bool
+	}{
+		{"/a.S/M=10:5", Limit{Rate: 10, Burst: 5}, true},
+		{"/a.S/M=0.5:0", Limit{Rate: 0.5}, true},
+		{"/a.S/M=10", Limit{}, false},
+		{"/a.S/M=-1:5", Limit{}, false},
+		{"/a.S/M=1:-5", Limit{}, false},
+		{"/a.S/M=x:5", Limit{}, false},
+	}
+	for _, tt := range tests {
+		m := MethodLimits{}
+		err := m.Set(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("Set(%q) = %v, want ok %v", tt.in, err, tt.ok)
+			continue
+		}
+		if tt.ok && m["/a.S/M"] != tt.want {
+			t.Errorf("Set(%q) = %+v, want %+v", tt.in, m["/a.S/M"], tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Package grpcserver contains the plumbing shared by the gRPC servers:
+// configuration, interceptors, message size limits, compression, metrics,
+// health checking, reflection and graceful shutdown.
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"expvar"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rsorage/grpc-go-course/compression"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server is a grpc.Server with the health and reflection services attached.
+type Server struct {
+	*grpc.Server
+
+	Health *health.Server
+
+	cfg Config
+}
+
+// New creates a server from the given configuration. Services must be
+// registered on the returned server before calling ListenAndServe.
+func New(cfg Config, opts ...grpc.ServerOption) (*Server, error) {
+	if cfg.TLS {
+		creds, err := loadTLSCredentials(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	sizes, err := newSizeLimiter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, sizes.serverOptions()...)
+
+	limiter := newRateLimiter(cfg)
+	deadlines, err := newDeadlineEnforcer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	unary := []grpc.UnaryServerInterceptor{unaryLogger, limiter.unary, deadlines.unary, sizes.unary}
+	stream := []grpc.StreamServerInterceptor{streamLogger, limiter.stream, deadlines.stream, sizes.stream}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: cfg.MaxConnectionIdle,
+			Time:              cfg.KeepaliveTime,
+			Timeout:           cfg.KeepaliveTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.KeepaliveMinTime,
+			PermitWithoutStream: cfg.KeepalivePermitWithoutStream,
+		}),
+		grpc.StatsHandler(compression.NewStatsHandler()),
+	)
+
+	s := &Server{
+		Server: grpc.NewServer(opts...),
+		Health: health.NewServer(),
+		cfg:    cfg,
+	}
+	healthpb.RegisterHealthServer(s.Server, s.Health)
+	reflection.Register(s.Server)
+
+	return s, nil
+}
+
+func loadTLSCredentials(cfg Config) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading certificates: %v", err)
+	}
+	tlsCfg := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if cfg.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
+
+// ListenAndServe listens on the configured address and serves until SIGINT
+// or SIGTERM is received, at which point the server is shut down gracefully.
+func (s *Server) ListenAndServe() error {
+	lis, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	for name := range s.GetServiceInfo() {
+		s.Health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	if s.cfg.MetricsAddr != "" {
+		metrics, err := s.serveMetrics()
+		if err != nil {
+			lis.Close()
+			return err
+		}
+		defer metrics.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s...", lis.Addr())
+		errCh <- s.Serve(lis)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Stopping the server...")
+	s.Shutdown()
+
+	return <-errCh
+}
+
+// serveMetrics serves the metrics of the servers over HTTP on the metrics
+// address. Only the vars listed in metricsVars are exposed: the others,
+// such as cmdline, may hold secrets.
+func (s *Server) serveMetrics() (*http.Server, error) {
+	lis, err := net.Listen("tcp", s.cfg.MetricsAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for metrics: %v", err)
+	}
+
+	if addr, ok := lis.Addr().(*net.TCPAddr); ok && !addr.IP.IsLoopback() {
+		log.Printf("Warning: metrics are served without authentication on %s, beyond localhost", addr)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/vars", serveVars)
+	srv := &http.Server{Handler: mux}
+
+	go func() {
+		log.Printf("Serving metrics on http://%s/debug/vars", lis.Addr())
+		if err := srv.Serve(lis); err != http.ErrServerClosed {
+			log.Printf("Metrics server failed: %v", err)
+		}
+	}()
+	return srv, nil
+}
+
+// metricsVars are the expvars served by the metrics server.
+var metricsVars = []string{compression.MetricsVar}
+
+// serveVars writes metricsVars as a JSON object, like expvar.Handler.
+func serveVars(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprint(w, "{")
+	first := true
+	for _, name := range metricsVars {
+		v := expvar.Get(name)
+		if v == nil {
+			continue
+		}
+		if !first {
+			fmt.Fprint(w, ",")
+		}
+		first = false
+		fmt.Fprintf(w, "\n%q: %s", name, v)
+	}
+	fmt.Fprint(w, "\n}\n")
+}
+
+// Shutdown marks every service as NOT_SERVING and waits for in-flight RPCs
+// to finish. If they take longer than the drain timeout, the remaining
+// connections are closed forcibly.
+func (s *Server) Shutdown() {
+	s.Health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("All RPCs drained.")
+	case <-time.After(s.cfg.DrainTimeout):
+		log.Printf("RPCs still running after %v, forcing stop...", s.cfg.DrainTimeout)
+		s.Stop()
+		<-done
+	}
+}
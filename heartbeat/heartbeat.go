@@ -0,0 +1,194 @@
+// Package heartbeat implements application level heartbeats on long-lived
+// bidirectional streams. Clients ping at a fixed interval and servers answer
+// each ping with a pong. Either side considers its peer dead when it has not
+// received anything from it for Misses intervals.
+//
+// Unlike HTTP/2 keepalive pings, heartbeats are ordinary stream messages, so
+// they also keep streams alive through proxies that only count data as
+// activity, and they detect peers that hold the connection open but no
+// longer serve the stream.
+package heartbeat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Misses is how many ping intervals a peer may stay silent before it is
+// considered dead.
+const Misses = 3
+
+// Bounds of the ping interval accepted by Watcher.Pinged.
+const (
+	MinInterval = 100 * time.Millisecond
+	MaxInterval = time.Hour
+)
+
+// DefaultInterval is the ping interval of the course clients.
+const DefaultInterval = 15 * time.Second
+
+// Timeout is how long a peer pinging every interval may stay silent.
+func Timeout(interval time.Duration) time.Duration {
+	return Misses * interval
+}
+
+// Watcher receives the messages of a stream on its own goroutine, so that
+// the receiving side can notice when the peer goes silent.
+type Watcher struct {
+	msgs chan interface{}
+	errs chan error
+
+	mu      sync.Mutex
+	timeout time.Duration
+	last    time.Time
+	// busy is set while a message waits to be taken from Messages; the
+	// peer is not silent then, the receiving side is just slow.
+	busy    bool
+	changed chan struct{}
+}
+
+// Watch starts receiving messages with recv until it fails or ctx is done.
+// If timeout is not zero, the peer is considered dead when no message is
+// received for that long; it can be changed later with SetTimeout.
+func Watch(ctx context.Context, recv func() (interface{}, error), timeout time.Duration) *Watcher {
+	w := &Watcher{
+		msgs:    make(chan interface{}),
+		errs:    make(chan error, 2),
+		timeout: timeout,
+		last:    time.Now(),
+		changed: make(chan struct{}, 1),
+	}
+	go w.receive(ctx, recv)
+	go w.watchdog(ctx)
+	return w
+}
+
+// Messages returns the received messages.
+func (w *Watcher) Messages() <-chan interface{} {
+	return w.msgs
+}
+
+// Err returns the error that ended the stream: the error of recv, io.EOF
+// included, or an UNAVAILABLE status when the peer went silent.
+func (w *Watcher) Err() <-chan error {
+	return w.errs
+}
+
+// SetTimeout changes how long the peer may stay silent. Zero disables the
+// detection of dead peers.
+func (w *Watcher) SetTimeout(d time.Duration) {
+	w.mu.Lock()
+	w.timeout = d
+	w.mu.Unlock()
+
+	select {
+	case w.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Pinged handles a ping announcing the peer pings every interval, expecting
+// it to do so from now on. It returns an INVALID_ARGUMENT status if the
+// interval is out of bounds.
+func (w *Watcher) Pinged(interval *durationpb.Duration) error {
+	if interval == nil {
+		return nil
+	}
+	d := interval.AsDuration()
+	if d < MinInterval || d > MaxInterval {
+		return status.Errorf(codes.InvalidArgument, "Heartbeat interval must be between %v and %v, got %v", MinInterval, MaxInterval, d)
+	}
+	w.SetTimeout(Timeout(d))
+	return nil
+}
+
+func (w *Watcher) receive(ctx context.Context, recv func() (interface{}, error)) {
+	for {
+		msg, err := recv()
+		if err != nil {
+			w.errs <- err
+			return
+		}
+
+		w.setBusy(true)
+		select {
+		case w.msgs <- msg:
+		case <-ctx.Done():
+			return
+		}
+		w.setBusy(false)
+	}
+}
+
+func (w *Watcher) setBusy(busy bool) {
+	w.mu.Lock()
+	w.busy = busy
+	w.last = time.Now()
+	w.mu.Unlock()
+}
+
+func (w *Watcher) watchdog(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		w.mu.Lock()
+		timeout, silence, busy := w.timeout, time.Since(w.last), w.busy
+		w.mu.Unlock()
+
+		// a negative wait leaves the timer stopped
+		wait := time.Duration(-1)
+		switch {
+		case timeout <= 0:
+		case busy:
+			wait = timeout
+		case silence >= timeout:
+			w.errs <- status.Errorf(codes.Unavailable, "Peer silent for %v, no heartbeat within %v", silence.Round(time.Millisecond), timeout)
+			return
+		default:
+			wait = timeout - silence
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		var fired <-chan time.Time
+		if wait >= 0 {
+			timer.Reset(wait)
+			fired = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.changed:
+		case <-fired:
+		}
+	}
+}
+
+// Ping calls ping with increasing ids from 1 every interval, until ctx is
+// done or ping fails.
+func Ping(ctx context.Context, interval time.Duration, ping func(id int64) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for id := int64(1); ; id++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := ping(id); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"strings"
+
+	"github.com/rsorage/grpc-go-course/blog/blogpb"
+	"github.com/rsorage/grpc-go-course/blog/blogserver"
+	"github.com/rsorage/grpc-go-course/calculator/calculatorpb"
+	"github.com/rsorage/grpc-go-course/calculator/calculatorserver"
+	"github.com/rsorage/grpc-go-course/calculator/session"
+	"github.com/rsorage/grpc-go-course/greet/chat"
+	"github.com/rsorage/grpc-go-course/greet/greetpb"
+	"github.com/rsorage/grpc-go-course/greet/greetserver"
+	"github.com/rsorage/grpc-go-course/greet/templates"
+	"github.com/rsorage/grpc-go-course/grpcserver"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Hosts every service of the course on a single port.
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	cfg := grpcserver.DefaultConfig("0.0.0.0:50051")
+	cfg.RegisterFlags(flag.CommandLine)
+	enableGreet := flag.Bool("greet", true, "serve GreetService")
+	enableCalculator := flag.Bool("calculator", true, "serve CalculatorService")
+	enableBlog := flag.Bool("blog", true, "serve BlogService")
+	mongoURI := flag.String("mongo-uri", "mongodb://localhost:27017", "MongoDB connection URI")
+	opTimeout := flag.Duration("mongo-op-timeout", blogserver.DefaultOperationTimeout, "longest MongoDB operation, also bounded by the deadline of the call")
+	maxContent := grpcserver.ByteSize(blogserver.DefaultMaxContentSize)
+	flag.Var(&maxContent, "blog-max-content", "largest blog content accepted, e.g. 1MiB, at most 15MiB")
+	templatesDir := flag.String("greet-templates", "", "directory of *.tmpl greeting templates to load at startup")
+	tokenFile := flag.String("greet-template-token-file", "", "file holding the operator token allowing clients to create templates (disabled if empty)")
+	allowReplace := flag.Bool("greet-template-replace", false, "allow operators to replace existing templates")
+	chatHistory := flag.Int("chat-history", chat.DefaultHistory, "chat events replayed to members joining a room")
+	chatBuffer := flag.Int("chat-buffer", chat.DefaultBuffer, "chat events a member may fall behind before being disconnected")
+	sessionTTL := flag.Duration("session-ttl", session.DefaultTTL, "idle time after which calculator sessions expire")
+	maxSessions := flag.Int("max-sessions", session.DefaultMaxSessions, "calculator sessions kept at once")
+	flag.Parse()
+
+	s, err := grpcserver.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
+
+	if *enableGreet {
+		log.Println("Registering GreetService...")
+		registry := templates.NewRegistry()
+		if *templatesDir != "" {
+			if err := registry.LoadDir(*templatesDir); err != nil {
+				log.Fatalf("Failed to load templates: %v", err)
+			}
+		}
+		var operatorToken string
+		if *tokenFile != "" {
+			b, err := ioutil.ReadFile(*tokenFile)
+			if err != nil {
+				log.Fatalf("Failed to read the template token: %v", err)
+			}
+			operatorToken = strings.TrimSpace(string(b))
+		}
+		greetpb.RegisterGreetServiceServer(s.Server, greetserver.New(
+			greetserver.WithTemplates(registry),
+			greetserver.WithOperatorToken(operatorToken),
+			greetserver.WithTemplateReplace(*allowReplace),
+			greetserver.WithChatHub(chat.NewHub(chat.WithHistory(*chatHistory), chat.WithBuffer(*chatBuffer))),
+		))
+	}
+
+	if *enableCalculator {
+		log.Println("Registering CalculatorService...")
+		calculatorpb.RegisterCalculatorServiceServer(s.Server, calculatorserver.New(
+			calculatorserver.WithSessionStore(session.NewMemoryStore(*sessionTTL, *maxSessions)),
+		))
+	}
+
+	var client *mongo.Client
+	if *enableBlog {
+		log.Println("Registering BlogService...")
+		client = blogserver.ConnectMongo(*mongoURI)
+		collection := client.Database("grpc_blogs").Collection("blogs")
+		blogService, err := blogserver.New(collection,
+			blogserver.WithOperationTimeout(*opTimeout),
+			blogserver.WithMaxContentSize(int(maxContent)),
+		)
+		if err != nil {
+			log.Fatalf("Failed to create blog service: %v", err)
+		}
+		blogpb.RegisterBlogServiceServer(s.Server, blogService)
+	}
+
+	if err := s.ListenAndServe(); err != nil {
+		log.Printf("Failed to serve: %v", err)
+	}
+
+	if client != nil {
+		log.Println("Closing MongoDB connection...")
+		client.Disconnect(context.TODO())
+	}
+	log.Println("Bye!")
+}